@@ -0,0 +1,503 @@
+/*
+Package ast - Typed representation of the PuppetDB AST query language.
+
+Each operator of the query language is modelled as a Go value which marshals
+itself to the JSON array form PuppetDB expects, so values are always escaped
+correctly and queries can be composed without string splicing:
+
+	q := ast.And{
+		ast.Equals{Field: "facts_environment", Value: "production"},
+		ast.Not{Query: ast.Null{Field: "deactivated", IsNull: false}},
+	}
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/ast.html
+*/
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+/*
+Query - Any node of the AST query language.
+
+All operator types in this package implement Query. Op returns the operator
+name as it appears in the first element of the serialized array.
+*/
+type Query interface {
+	json.Marshaler
+	Op() string
+}
+
+/*
+Marshal - Serialize a query into its JSON string form.
+*/
+func Marshal(q Query) (string, error) {
+	if q == nil {
+		return "", errors.New("ast: nil query")
+	}
+	b, err := encode(q)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// encode is json.Marshal without HTML escaping, so operators such as "<"
+// stay readable in URLs and logs.
+func encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+func marshalOp(op string, args ...interface{}) ([]byte, error) {
+	return encode(append([]interface{}{op}, args...))
+}
+
+/*
+Equals - ["=", field, value]
+*/
+type Equals struct {
+	Field string
+	Value interface{}
+}
+
+// Op returns the operator name
+func (q Equals) Op() string { return "=" }
+
+// MarshalJSON implements json.Marshaler
+func (q Equals) MarshalJSON() ([]byte, error) { return marshalOp(q.Op(), q.Field, q.Value) }
+
+/*
+GreaterThan - [">", field, value]
+*/
+type GreaterThan struct {
+	Field string
+	Value interface{}
+}
+
+// Op returns the operator name
+func (q GreaterThan) Op() string { return ">" }
+
+// MarshalJSON implements json.Marshaler
+func (q GreaterThan) MarshalJSON() ([]byte, error) { return marshalOp(q.Op(), q.Field, q.Value) }
+
+/*
+GreaterThanOrEqual - [">=", field, value]
+*/
+type GreaterThanOrEqual struct {
+	Field string
+	Value interface{}
+}
+
+// Op returns the operator name
+func (q GreaterThanOrEqual) Op() string { return ">=" }
+
+// MarshalJSON implements json.Marshaler
+func (q GreaterThanOrEqual) MarshalJSON() ([]byte, error) {
+	return marshalOp(q.Op(), q.Field, q.Value)
+}
+
+/*
+LessThan - ["<", field, value]
+*/
+type LessThan struct {
+	Field string
+	Value interface{}
+}
+
+// Op returns the operator name
+func (q LessThan) Op() string { return "<" }
+
+// MarshalJSON implements json.Marshaler
+func (q LessThan) MarshalJSON() ([]byte, error) { return marshalOp(q.Op(), q.Field, q.Value) }
+
+/*
+LessThanOrEqual - ["<=", field, value]
+*/
+type LessThanOrEqual struct {
+	Field string
+	Value interface{}
+}
+
+// Op returns the operator name
+func (q LessThanOrEqual) Op() string { return "<=" }
+
+// MarshalJSON implements json.Marshaler
+func (q LessThanOrEqual) MarshalJSON() ([]byte, error) { return marshalOp(q.Op(), q.Field, q.Value) }
+
+/*
+Regex - ["~", field, pattern]
+*/
+type Regex struct {
+	Field   string
+	Pattern string
+}
+
+// Op returns the operator name
+func (q Regex) Op() string { return "~" }
+
+// MarshalJSON implements json.Marshaler
+func (q Regex) MarshalJSON() ([]byte, error) { return marshalOp(q.Op(), q.Field, q.Pattern) }
+
+/*
+RegexArray - ["~>", field, [pattern, ...]]
+
+Matches array fields, such as the path of fact-contents and fact-paths, whose
+elements each match the corresponding pattern.
+*/
+type RegexArray struct {
+	Field    string
+	Patterns []string
+}
+
+// Op returns the operator name
+func (q RegexArray) Op() string { return "~>" }
+
+// MarshalJSON implements json.Marshaler
+func (q RegexArray) MarshalJSON() ([]byte, error) {
+	patterns := q.Patterns
+	if patterns == nil {
+		patterns = []string{}
+	}
+	return marshalOp(q.Op(), q.Field, patterns)
+}
+
+/*
+PathEquals - Match a fact path exactly, such as PathEquals("os", "release", "major").
+Array indexes within structured facts are given as ints.
+*/
+func PathEquals(path ...interface{}) Equals {
+	if path == nil {
+		path = []interface{}{}
+	}
+	return Equals{Field: "path", Value: path}
+}
+
+/*
+PathMatches - Match fact paths by a regular expression per element, such as
+PathMatches("networking", "interfaces", ".*", "ip") for the address of every
+interface.
+*/
+func PathMatches(patterns ...string) RegexArray {
+	return RegexArray{Field: "path", Patterns: patterns}
+}
+
+/*
+Null - ["null?", field, bool]
+
+Matches when the field is null (IsNull true) or not null (IsNull false).
+*/
+type Null struct {
+	Field  string
+	IsNull bool
+}
+
+// Op returns the operator name
+func (q Null) Op() string { return "null?" }
+
+// MarshalJSON implements json.Marshaler
+func (q Null) MarshalJSON() ([]byte, error) { return marshalOp(q.Op(), q.Field, q.IsNull) }
+
+/*
+And - ["and", query...]
+*/
+type And []Query
+
+// Op returns the operator name
+func (q And) Op() string { return "and" }
+
+// MarshalJSON implements json.Marshaler
+func (q And) MarshalJSON() ([]byte, error) { return marshalOp(q.Op(), queryArgs(q)...) }
+
+/*
+Or - ["or", query...]
+*/
+type Or []Query
+
+// Op returns the operator name
+func (q Or) Op() string { return "or" }
+
+// MarshalJSON implements json.Marshaler
+func (q Or) MarshalJSON() ([]byte, error) { return marshalOp(q.Op(), queryArgs(q)...) }
+
+func queryArgs(qs []Query) []interface{} {
+	args := make([]interface{}, len(qs))
+	for i, q := range qs {
+		args[i] = q
+	}
+	return args
+}
+
+/*
+Not - ["not", query]
+*/
+type Not struct {
+	Query Query
+}
+
+// Op returns the operator name
+func (q Not) Op() string { return "not" }
+
+// MarshalJSON implements json.Marshaler
+func (q Not) MarshalJSON() ([]byte, error) {
+	if q.Query == nil {
+		return nil, errors.New("ast: not without a query")
+	}
+	return marshalOp(q.Op(), q.Query)
+}
+
+/*
+In - ["in", field(s), source]
+
+Source is either an Array of literal values or a query producing rows, such
+as Extract or a From query. When more than one field is given they are
+serialized as an array and matched against the columns of the source.
+*/
+type In struct {
+	Fields []string
+	Source Query
+}
+
+// Op returns the operator name
+func (q In) Op() string { return "in" }
+
+// MarshalJSON implements json.Marshaler
+func (q In) MarshalJSON() ([]byte, error) {
+	if len(q.Fields) == 0 {
+		return nil, errors.New("ast: in without any fields")
+	}
+	if q.Source == nil {
+		return nil, errors.New("ast: in without a source")
+	}
+	if len(q.Fields) == 1 {
+		return marshalOp(q.Op(), q.Fields[0], q.Source)
+	}
+	return marshalOp(q.Op(), q.Fields, q.Source)
+}
+
+/*
+Array - ["array", [value...]]
+
+Literal list of values, for use as the source of an In query.
+*/
+type Array []interface{}
+
+// Op returns the operator name
+func (q Array) Op() string { return "array" }
+
+// MarshalJSON implements json.Marshaler
+func (q Array) MarshalJSON() ([]byte, error) {
+	values := []interface{}(q)
+	if values == nil {
+		values = []interface{}{}
+	}
+	return marshalOp(q.Op(), values)
+}
+
+/*
+Function - ["function", name, args...]
+
+Aggregate function for use as an Extract column, such as count or avg.
+*/
+type Function struct {
+	Name string
+	Args []string
+}
+
+// Op returns the operator name
+func (q Function) Op() string { return "function" }
+
+// MarshalJSON implements json.Marshaler
+func (q Function) MarshalJSON() ([]byte, error) {
+	args := []interface{}{q.Name}
+	for _, arg := range q.Args {
+		args = append(args, arg)
+	}
+	return marshalOp(q.Op(), args...)
+}
+
+/*
+GroupBy - ["group_by", field...]
+*/
+type GroupBy []string
+
+// Op returns the operator name
+func (q GroupBy) Op() string { return "group_by" }
+
+// MarshalJSON implements json.Marshaler
+func (q GroupBy) MarshalJSON() ([]byte, error) {
+	args := make([]interface{}, len(q))
+	for i, field := range q {
+		args[i] = field
+	}
+	return marshalOp(q.Op(), args...)
+}
+
+/*
+Extract - ["extract", [column...], query, group_by]
+
+Restricts the returned columns to Fields plus any aggregate Functions. Query
+and GroupBy are optional.
+*/
+type Extract struct {
+	Fields    []string
+	Functions []Function
+	Query     Query
+	GroupBy   GroupBy
+}
+
+// Op returns the operator name
+func (q Extract) Op() string { return "extract" }
+
+// MarshalJSON implements json.Marshaler
+func (q Extract) MarshalJSON() ([]byte, error) {
+	columns := []interface{}{}
+	for _, fn := range q.Functions {
+		columns = append(columns, fn)
+	}
+	for _, field := range q.Fields {
+		columns = append(columns, field)
+	}
+	args := []interface{}{columns}
+	if q.Query != nil {
+		args = append(args, q.Query)
+	}
+	if len(q.GroupBy) > 0 {
+		args = append(args, q.GroupBy)
+	}
+	return marshalOp(q.Op(), args...)
+}
+
+/*
+Subquery - ["subquery", entity, query]
+
+Implicit subquery, joining the queried entity to Entity on their common key,
+for example all nodes with a given fact.
+*/
+type Subquery struct {
+	Entity string
+	Query  Query
+}
+
+// Op returns the operator name
+func (q Subquery) Op() string { return "subquery" }
+
+// MarshalJSON implements json.Marshaler
+func (q Subquery) MarshalJSON() ([]byte, error) {
+	if q.Query == nil {
+		return marshalOp(q.Op(), q.Entity)
+	}
+	return marshalOp(q.Op(), q.Entity, q.Query)
+}
+
+/*
+Select - ["select_<entity>", query]
+
+Explicit subquery against Entity, normally wrapped in an Extract and used as
+the source of an In query.
+*/
+type Select struct {
+	Entity string
+	Query  Query
+}
+
+// Op returns the operator name
+func (q Select) Op() string { return "select_" + q.Entity }
+
+// MarshalJSON implements json.Marshaler
+func (q Select) MarshalJSON() ([]byte, error) {
+	if q.Query == nil {
+		return marshalOp(q.Op())
+	}
+	return marshalOp(q.Op(), q.Query)
+}
+
+/*
+Raw - A pre-serialized AST query, sent as is.
+
+Useful when migrating hand-built query strings. The contents must be valid
+JSON.
+*/
+type Raw string
+
+// Op returns the operator name, or an empty string if it can't be determined
+func (q Raw) Op() string {
+	var parts []json.RawMessage
+	var op string
+	if json.Unmarshal([]byte(q), &parts) != nil || len(parts) == 0 {
+		return ""
+	}
+	if json.Unmarshal(parts[0], &op) != nil {
+		return ""
+	}
+	return op
+}
+
+// MarshalJSON implements json.Marshaler
+func (q Raw) MarshalJSON() ([]byte, error) {
+	if !json.Valid([]byte(q)) {
+		return nil, errors.New("ast: raw query is not valid JSON")
+	}
+	return []byte(q), nil
+}
+
+/*
+OrderBy - A single ordering clause of a From query.
+*/
+type OrderBy struct {
+	Field      string
+	Descending bool
+}
+
+// MarshalJSON implements json.Marshaler
+func (o OrderBy) MarshalJSON() ([]byte, error) {
+	order := "asc"
+	if o.Descending {
+		order = "desc"
+	}
+	return encode([]string{o.Field, order})
+}
+
+/*
+From - ["from", entity, query, paging...]
+
+Selects the entity to query, which allows a query to be sent to the root
+query end-point or used as the source of an In query. Limit and Offset are
+omitted when zero.
+*/
+type From struct {
+	Entity  string
+	Query   Query
+	OrderBy []OrderBy
+	Limit   int
+	Offset  int
+}
+
+// Op returns the operator name
+func (q From) Op() string { return "from" }
+
+// MarshalJSON implements json.Marshaler
+func (q From) MarshalJSON() ([]byte, error) {
+	args := []interface{}{q.Entity}
+	if q.Query != nil {
+		args = append(args, q.Query)
+	}
+	if len(q.OrderBy) > 0 {
+		args = append(args, []interface{}{"order_by", q.OrderBy})
+	}
+	if q.Limit > 0 {
+		args = append(args, []interface{}{"limit", q.Limit})
+	}
+	if q.Offset > 0 {
+		args = append(args, []interface{}{"offset", q.Offset})
+	}
+	return marshalOp(q.Op(), args...)
+}
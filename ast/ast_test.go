@@ -0,0 +1,94 @@
+package ast
+
+import "testing"
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{"equals", Equals{"certname", "foo"}, `["=","certname","foo"]`},
+		{"escaping", Equals{"certname", `fo"o`}, `["=","certname","fo\"o"]`},
+		{"number", GreaterThanOrEqual{"facts.processorcount", 4}, `[">=","facts.processorcount",4]`},
+		{"regex", Regex{"certname", `^web\d+`}, `["~","certname","^web\\d+"]`},
+		{"null", Null{"deactivated", true}, `["null?","deactivated",true]`},
+		{"path equals", PathEquals("os", "release", "major"), `["=","path",["os","release","major"]]`},
+		{"path matches", PathMatches("networking", "interfaces", ".*", "ip"), `["~>","path",["networking","interfaces",".*","ip"]]`},
+		{
+			"and/or/not",
+			And{Equals{"name", "os"}, Or{LessThan{"value", 3}, Not{Equals{"value", "x"}}}},
+			`["and",["=","name","os"],["or",["<","value",3],["not",["=","value","x"]]]]`,
+		},
+		{"in array", In{[]string{"certname"}, Array{"a", "b"}}, `["in","certname",["array",["a","b"]]]`},
+		{
+			"in extract select",
+			In{[]string{"certname"}, Extract{Fields: []string{"certname"}, Query: Select{"facts", Equals{"name", "os"}}}},
+			`["in","certname",["extract",["certname"],["select_facts",["=","name","os"]]]]`,
+		},
+		{
+			"extract group by",
+			Extract{Fields: []string{"status"}, Functions: []Function{{Name: "count"}}, Query: Equals{"certname", "foo"}, GroupBy: GroupBy{"status"}},
+			`["extract",[["function","count"],"status"],["=","certname","foo"],["group_by","status"]]`,
+		},
+		{"subquery", Subquery{"facts", Equals{"name", "os"}}, `["subquery","facts",["=","name","os"]]`},
+		{"raw", Raw(`["=", "certname", "foo"]`), `["=","certname","foo"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+	}{
+		{"not without query", Not{}},
+		{"nested not without query", And{Equals{"certname", "foo"}, Not{}}},
+		{"in without fields", In{Source: Array{"a"}}},
+		{"in with empty fields", In{[]string{}, Array{"a"}}},
+		{"in without source", In{Fields: []string{"certname"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Marshal(tt.query); err == nil {
+				t.Errorf("Marshal() = %s, expected an error", got)
+			}
+		})
+	}
+}
+
+func TestRawInvalid(t *testing.T) {
+	if _, err := Marshal(Raw(`["=", "certname"`)); err == nil {
+		t.Error("expected an error for invalid raw JSON")
+	}
+	if op := Raw(`["=", "certname", "foo"]`).Op(); op != "=" {
+		t.Errorf("Op() = %q, want =", op)
+	}
+}
+
+func TestMarshalFrom(t *testing.T) {
+	q := From{
+		Entity:  "nodes",
+		Query:   Extract{Fields: []string{"certname"}, Query: Equals{"certname", "foo"}},
+		OrderBy: []OrderBy{{Field: "certname", Descending: true}},
+		Limit:   10,
+	}
+	want := `["from","nodes",["extract",["certname"],["=","certname","foo"]],["order_by",[["certname","desc"]]],["limit",10]]`
+	got, err := Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,238 @@
+package puppetdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+/*
+ErrCannotRefresh - Returned by Authenticator.Refresh when there is no way to
+obtain new credentials, so the rejected request is not replayed.
+*/
+var ErrCannotRefresh = errors.New("puppetdb: authenticator cannot refresh credentials")
+
+/*
+Authenticator - Supplies credentials for each request a Server makes.
+
+Authenticate is called on every request before it is sent. When PuppetDB
+answers with 401 Unauthorized, Refresh is called once and, if it succeeds, the
+request is replayed with whatever Authenticate then adds.
+*/
+type Authenticator interface {
+	Authenticate(ctx context.Context, req *http.Request) error
+	Refresh(ctx context.Context) error
+}
+
+// SetAuthenticator sets the Authenticator consulted on every request
+func (s *Server) SetAuthenticator(authenticator Authenticator) {
+	s.Authenticator = authenticator
+}
+
+/*
+StaticToken - Authenticates with a fixed Puppet Enterprise RBAC token.
+*/
+type StaticToken string
+
+// Authenticate adds the token to the request
+func (t StaticToken) Authenticate(ctx context.Context, req *http.Request) error {
+	req.Header.Set("X-Authentication", string(t))
+	return nil
+}
+
+// Refresh always fails, a static token can't be renewed
+func (t StaticToken) Refresh(ctx context.Context) error {
+	return ErrCannotRefresh
+}
+
+/*
+TokenFileAuthenticator - Authenticates with an RBAC token read from a file,
+such as the one maintained by puppet-access, reloading it whenever the file
+changes.
+*/
+type TokenFileAuthenticator struct {
+	Path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+}
+
+/*
+NewTokenFileAuthenticator - Create a TokenFileAuthenticator for path, or for
+~/.puppetlabs/token when path is empty.
+*/
+func NewTokenFileAuthenticator(path string) (*TokenFileAuthenticator, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultTokenFile(); err != nil {
+			return nil, err
+		}
+	}
+	return &TokenFileAuthenticator{Path: path}, nil
+}
+
+func (a *TokenFileAuthenticator) load(force bool) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.Path)
+	if err != nil {
+		return "", err
+	}
+	if force || a.token == "" || !info.ModTime().Equal(a.modTime) {
+		token, err := ioutil.ReadFile(a.Path)
+		if err != nil {
+			return "", err
+		}
+		a.token = string(bytes.TrimSpace(token))
+		a.modTime = info.ModTime()
+	}
+	return a.token, nil
+}
+
+// Authenticate adds the token from the file to the request
+func (a *TokenFileAuthenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	token, err := a.load(false)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Authentication", token)
+	return nil
+}
+
+// Refresh re-reads the token file, which may have been renewed since it was
+// last loaded
+func (a *TokenFileAuthenticator) Refresh(ctx context.Context) error {
+	_, err := a.load(true)
+	return err
+}
+
+/*
+RBACAuthenticator - Authenticates with tokens obtained from the Puppet
+Enterprise RBAC API, logging in again when a token is rejected.
+*/
+type RBACAuthenticator struct {
+	Client *RBACClient
+}
+
+// Authenticate adds a valid RBAC token to the request
+func (a RBACAuthenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	token, err := a.Client.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Authentication", token)
+	return nil
+}
+
+/*
+Refresh - Discard the rejected token and log in for a new one.
+
+Without credentials to log in with, the token file is left alone, since other
+tools such as puppet-access may depend on it, and ErrCannotRefresh is returned.
+*/
+func (a RBACAuthenticator) Refresh(ctx context.Context) error {
+	if a.Client.Credentials == nil {
+		return ErrCannotRefresh
+	}
+	if err := a.Client.Invalidate(); err != nil {
+		return err
+	}
+	_, err := a.Client.Login(ctx)
+	return err
+}
+
+/*
+ClientCertAuthenticator - Relies solely on the TLS client certificate, as set
+up by NewMutualTLSServer, and adds nothing to requests.
+
+Useful as the last resort of a ChainAuthenticator.
+*/
+type ClientCertAuthenticator struct{}
+
+// Authenticate leaves the request as is
+func (ClientCertAuthenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	return nil
+}
+
+// Refresh always fails, the certificate is fixed for the life of the Server
+func (ClientCertAuthenticator) Refresh(ctx context.Context) error {
+	return ErrCannotRefresh
+}
+
+/*
+ChainAuthenticator - Falls back through a list of authenticators.
+
+Requests use the first authenticator that can authenticate them. When
+PuppetDB rejects a request and the authenticator in use can't refresh, the
+next one in the chain takes over.
+*/
+type ChainAuthenticator struct {
+	mu             sync.Mutex
+	authenticators []Authenticator
+	current        int
+}
+
+/*
+NewChainAuthenticator - Create a ChainAuthenticator trying authenticators in
+the given order.
+*/
+func NewChainAuthenticator(authenticators ...Authenticator) *ChainAuthenticator {
+	return &ChainAuthenticator{authenticators: authenticators}
+}
+
+// Authenticate uses the first authenticator, from the current one on, that succeeds
+func (c *ChainAuthenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	// Authenticators may make requests of their own, so call them unlocked
+	c.mu.Lock()
+	current := c.current
+	authenticators := append([]Authenticator(nil), c.authenticators[current:]...)
+	c.mu.Unlock()
+
+	err := errors.New("puppetdb: no authenticators configured")
+	for i, authenticator := range authenticators {
+		if err = authenticator.Authenticate(ctx, req); err == nil {
+			c.mu.Lock()
+			if c.current < current+i {
+				c.current = current + i
+			}
+			c.mu.Unlock()
+			return nil
+		}
+	}
+	return err
+}
+
+// Refresh refreshes the current authenticator, or moves on to the next one
+func (c *ChainAuthenticator) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	current := c.current
+	if current >= len(c.authenticators) {
+		c.mu.Unlock()
+		return ErrCannotRefresh
+	}
+	authenticator := c.authenticators[current]
+	c.mu.Unlock()
+
+	if err := authenticator.Refresh(ctx); err == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current != current {
+		// Another request has already moved on
+		return nil
+	}
+	if c.current+1 < len(c.authenticators) {
+		c.current++
+		return nil
+	}
+	return ErrCannotRefresh
+}
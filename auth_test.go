@@ -0,0 +1,117 @@
+package puppetdb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuthenticatorRefresh(t *testing.T) {
+	var tokens []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Authentication")
+		tokens = append(tokens, token)
+		if token != "new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"version": "6.0.0"}`))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := NewTokenFileAuthenticator(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(ts.URL + "/")
+	server.SetAuthenticator(auth)
+
+	// Refreshing from an unchanged file replays the request once, to no avail
+	if _, err := server.QueryVersion(); !IsUnauthorized(err) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Errorf("made %d requests, want the original and one replay", len(tokens))
+	}
+
+	// A renewed token is picked up on the next request
+	tokens = nil
+	if err := ioutil.WriteFile(tokenFile, []byte("new\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Ensure the modification time moves on with coarse file system clocks
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(tokenFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.QueryVersion(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0] != "new" {
+		t.Errorf("unexpected tokens sent %q", tokens)
+	}
+
+	// A chain falls back to the next authenticator when refresh fails
+	tokens = nil
+	server.SetAuthenticator(NewChainAuthenticator(StaticToken("bad"), StaticToken("new")))
+	if _, err := server.QueryVersion(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0] != "bad" || tokens[1] != "new" {
+		t.Errorf("unexpected tokens sent %q", tokens)
+	}
+	// Authenticate keeps an authenticator that has already been set
+	tokens = nil
+	server.SetAuthenticator(StaticToken("new"))
+	server.SetRBACClient(NewRBACClient(ts.URL+"/rbac-api", nil))
+	if err := server.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0] != "new" {
+		t.Errorf("unexpected tokens sent %q", tokens)
+	}
+}
+
+func TestRBACAuthenticatorKeepsTokenFile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("shared"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without credentials the rejected token can't be replaced, so it must survive
+	client := NewRBACClient(ts.URL+"/rbac-api", nil)
+	client.TokenFile = tokenFile
+	server := NewServer(ts.URL + "/")
+	server.SetAuthenticator(RBACAuthenticator{Client: client})
+
+	if _, err := server.QueryVersion(); !IsUnauthorized(err) {
+		t.Errorf("expected an unauthorized error, got %v", err)
+	}
+	if saved, err := ioutil.ReadFile(tokenFile); err != nil || string(saved) != "shared" {
+		t.Errorf("token file holds %q, %v", saved, err)
+	}
+}
@@ -1,43 +1,43 @@
 package puppetdb
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
 /*
-CatalogWireFormat - Wire format representation of a catalog.
+CatalogWireFormat - Wire format representation of a catalog, version 9.
 
 You probably want to take a look at the NewCatalogWireFormat function, as this
 is the suggested way to create a new catalog wire format data structure from
 scratch.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/wire_format/catalog_format.html
-*/
-type CatalogWireFormat struct {
-	// Metadata for this catalog
-	Metadata CatalogMetadata `json:"metadata"`
-	// Data for this catalog
-	Data CatalogData `json:"data"`
-}
+Fields the format allows to be null are pointers, sent as an explicit null
+when nil.
 
-/*
-CatalogMetadata struct.
-
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/wire_format/catalog_format.html#main-data-type-catalog
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/catalog_format_v9.html
 */
-type CatalogMetadata struct {
-	// Catalog data API version
-	APIVersion int `json:"api_version"`
-}
-
-/*
-CatalogData struct
-
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/wire_format/catalog_format.html#main-data-type-catalog
-*/
-type CatalogData struct {
+type CatalogWireFormat struct {
 	// Certificate name owning the catalog to be replaced
-	Name string `json:"name"`
-	// Version of the catalog
+	Certname string `json:"certname"`
+	// Version of the catalog, such as the time it was compiled or a commit id
 	Version string `json:"version"`
-	// Unique identifier provided by client to marry catalogs with reports and other (future) objects
-	TransactionUUID string `json:"transaction-uuid"`
+	// Environment the catalog was compiled in
+	Environment string `json:"environment"`
+	// Identifier provided by the agent to marry the catalog with its report
+	TransactionUUID string `json:"transaction_uuid"`
+	// Identifier of this catalog, generated by the compiler
+	CatalogUUID string `json:"catalog_uuid"`
+	// Identifier of the code the catalog was compiled from, if known
+	CodeID *string `json:"code_id"`
+	// Identifier of the orchestrator job that requested the catalog, if any
+	JobID string `json:"job_id,omitempty"`
+	// Time the catalog was compiled, used to discard out of date commands
+	ProducerTimestamp time.Time `json:"producer_timestamp"`
+	// Certificate name of the Puppet Server that compiled the catalog, if known
+	Producer *string `json:"producer"`
 	// Edges represented in this catalog
 	Edges []CatalogEdge `json:"edges"`
 	// Resources represented in this catalog
@@ -48,21 +48,21 @@ type CatalogData struct {
 CatalogEdge struct
 A representation of an edge inside a catalog.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/wire_format/catalog_format.html#data-type-edge
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/catalog_format_v9.html#data-type-edge
 */
 type CatalogEdge struct {
 	// Source resource spec for this edge
 	Source CatalogResourceSpec `json:"source"`
 	// Target resource spec for this edge
 	Target CatalogResourceSpec `json:"target"`
-	// Relationship type
+	// Relationship type, such as contains, before or notifies
 	Relationship string `json:"relationship"`
 }
 
 /*
 CatalogResourceSpec struct represents a catalog resource reference for use in edges.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/wire_format/catalog_format.html#data-type-resource-spec
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/catalog_format_v9.html#data-type-resource-spec
 */
 type CatalogResourceSpec struct {
 	// The type of a catalog resource
@@ -71,6 +71,11 @@ type CatalogResourceSpec struct {
 	Title string `json:"title"`
 }
 
+// String formats the reference as Puppet does, such as File[/etc/hosts]
+func (spec CatalogResourceSpec) String() string {
+	return spec.Type + "[" + spec.Title + "]"
+}
+
 /*
 CatalogResources - Collection of catalog resources
 */
@@ -79,7 +84,7 @@ type CatalogResources []CatalogResource
 /*
 CatalogResource struct
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/wire_format/catalog_format.html#data-type-resource
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/catalog_format_v9.html#data-type-resource
 */
 type CatalogResource struct {
 	// The type of a catalog resource
@@ -87,24 +92,138 @@ type CatalogResource struct {
 	// The title of a catalog resource
 	Title string `json:"title"`
 	// Aliases for this resource
-	//Aliases []string `json:"aliases"`
+	Aliases []string `json:"aliases,omitempty"`
 	// Exported status
 	Exported bool `json:"exported"`
 	// Source file this resource appears in
-	File string `json:"file"`
+	File string `json:"file,omitempty"`
 	// Line in the file this resource appears in
-	Line int `json:"line"`
+	Line int `json:"line,omitempty"`
 	// All tags applied to this resource
 	Tags []string `json:"tags"`
-	// A map containing a list of key/value pairs for each parameter of this resource
-	Parameters map[string]string `json:"parameters"`
+	// Parameters of this resource by name, each any JSON value
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+// Spec returns the reference edges use for this resource
+func (r CatalogResource) Spec() CatalogResourceSpec {
+	return CatalogResourceSpec{r.Type, r.Title}
 }
 
 /*
 NewCatalogWireFormat - Create a new catalog
 */
 func NewCatalogWireFormat() CatalogWireFormat {
-	metadata := CatalogMetadata{0}
-	data := CatalogData{"", "", "", nil, nil}
-	return CatalogWireFormat{metadata, data}
+	return CatalogWireFormat{
+		ProducerTimestamp: time.Now(),
+		Edges:             []CatalogEdge{},
+		Resources:         []CatalogResource{},
+	}
+}
+
+/*
+Validate - Check the catalog is consistent before submitting it: every
+resource's type and title are unique, and every edge joins resources in the
+catalog.
+
+All problems found are reported in a single error.
+*/
+func (c CatalogWireFormat) Validate() error {
+	var problems []string
+	if c.Certname == "" {
+		problems = append(problems, "no certname")
+	}
+
+	resources := make(map[CatalogResourceSpec]bool, len(c.Resources))
+	for _, resource := range c.Resources {
+		spec := resource.Spec()
+		if resources[spec] {
+			problems = append(problems, "duplicate resource "+spec.String())
+		}
+		resources[spec] = true
+	}
+
+	for _, edge := range c.Edges {
+		if !resources[edge.Source] {
+			problems = append(problems, fmt.Sprintf("edge %s %s %s has no source resource", edge.Source, edge.Relationship, edge.Target))
+		}
+		if !resources[edge.Target] {
+			problems = append(problems, fmt.Sprintf("edge %s %s %s has no target resource", edge.Source, edge.Relationship, edge.Target))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid catalog: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+/*
+Catalog - A catalog as returned by the catalogs query end-point.
+
+Edges and resources are expanded in line, with a link to query them
+separately.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/catalogs.html#response-format
+*/
+type Catalog struct {
+	Certname          string            `json:"certname"`
+	Version           string            `json:"version"`
+	Environment       string            `json:"environment"`
+	TransactionUUID   string            `json:"transaction_uuid"`
+	CatalogUUID       string            `json:"catalog_uuid"`
+	CodeID            string            `json:"code_id"`
+	JobID             string            `json:"job_id"`
+	ProducerTimestamp time.Time         `json:"producer_timestamp"`
+	Producer          string            `json:"producer"`
+	Hash              string            `json:"hash"`
+	Edges             ExpandedEdges     `json:"edges"`
+	Resources         ExpandedResources `json:"resources"`
+}
+
+/*
+ExpandedEdges - Edges included in a query response, along with the URL path
+to query them on their own.
+*/
+type ExpandedEdges struct {
+	Href string `json:"href"`
+	Data []Edge `json:"data"`
+}
+
+/*
+ExpandedResources - Resources included in a query response, along with the
+URL path to query them on their own.
+*/
+type ExpandedResources struct {
+	Href string            `json:"href"`
+	Data []CatalogResource `json:"data"`
+}
+
+/*
+Edge - A relationship between two resources, as returned by query end-points.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/edges.html#response-format
+*/
+type Edge struct {
+	Certname     string `json:"certname,omitempty"`
+	Relationship string `json:"relationship"`
+	SourceType   string `json:"source_type"`
+	SourceTitle  string `json:"source_title"`
+	TargetType   string `json:"target_type"`
+	TargetTitle  string `json:"target_title"`
+}
+
+// Source returns a reference to the edge's source resource
+func (e Edge) Source() CatalogResourceSpec {
+	return CatalogResourceSpec{e.SourceType, e.SourceTitle}
+}
+
+// Target returns a reference to the edge's target resource
+func (e Edge) Target() CatalogResourceSpec {
+	return CatalogResourceSpec{e.TargetType, e.TargetTitle}
+}
+
+// CatalogEdge converts the edge to the catalog wire format
+func (e Edge) CatalogEdge() CatalogEdge {
+	return CatalogEdge{Source: e.Source(), Target: e.Target(), Relationship: e.Relationship}
 }
@@ -0,0 +1,78 @@
+package puppetdb
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCatalogValidate(t *testing.T) {
+	catalog := NewCatalogWireFormat()
+	catalog.Certname = "foo.example.com"
+	catalog.Resources = []CatalogResource{
+		{Type: "File", Title: "/etc", Parameters: map[string]interface{}{"ensure": "directory"}},
+		{Type: "File", Title: "/etc/hosts", Parameters: map[string]interface{}{"mode": "0644", "audit": []string{"owner"}}},
+	}
+	catalog.Edges = []CatalogEdge{
+		{Source: CatalogResourceSpec{"File", "/etc"}, Target: CatalogResourceSpec{"File", "/etc/hosts"}, Relationship: "before"},
+	}
+	if err := catalog.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wire map[string]json.RawMessage
+	json.Unmarshal(data, &wire)
+	for _, key := range []string{"certname", "catalog_uuid", "producer_timestamp", "edges", "resources"} {
+		if _, ok := wire[key]; !ok {
+			t.Errorf("no %s in %s", key, data)
+		}
+	}
+	for _, key := range []string{"code_id", "producer"} {
+		if value, ok := wire[key]; !ok || string(value) != "null" {
+			t.Errorf("%s = %s, want null in %s", key, value, data)
+		}
+	}
+
+	catalog.Resources = append(catalog.Resources, CatalogResource{Type: "File", Title: "/etc"})
+	catalog.Edges = append(catalog.Edges, CatalogEdge{
+		Source: CatalogResourceSpec{"Class", "main"}, Target: CatalogResourceSpec{"File", "/etc"}, Relationship: "contains",
+	})
+	err = catalog.Validate()
+	if err == nil || !strings.Contains(err.Error(), "duplicate resource File[/etc]") ||
+		!strings.Contains(err.Error(), "Class[main] contains File[/etc] has no source resource") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestCatalogDecode(t *testing.T) {
+	var catalog Catalog
+	err := json.Unmarshal([]byte(`{
+		"certname": "foo.example.com",
+		"version": "1600000000",
+		"environment": "production",
+		"catalog_uuid": "5ea3a70b-84c8-426c-813c-dd6492fb829b",
+		"producer_timestamp": "2020-09-13T12:26:40.000Z",
+		"edges": {"href": "/pdb/query/v4/catalogs/foo.example.com/edges", "data": [
+			{"relationship": "contains", "source_type": "Class", "source_title": "Main", "target_type": "File", "target_title": "/etc"}
+		]},
+		"resources": {"href": "/pdb/query/v4/catalogs/foo.example.com/resources", "data": [
+			{"type": "File", "title": "/etc", "tags": ["file"], "exported": false, "parameters": {"ensure": "directory", "recurse": true}}
+		]}
+	}`), &catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if catalog.ProducerTimestamp.Year() != 2020 || len(catalog.Edges.Data) != 1 || len(catalog.Resources.Data) != 1 {
+		t.Errorf("unexpected catalog %+v", catalog)
+	}
+	if catalog.Edges.Data[0].Target() != catalog.Resources.Data[0].Spec() {
+		t.Errorf("edge target %s doesn't match resource", catalog.Edges.Data[0].Target())
+	}
+	if catalog.Resources.Data[0].Parameters["recurse"] != true {
+		t.Errorf("unexpected parameters %v", catalog.Resources.Data[0].Parameters)
+	}
+}
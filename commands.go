@@ -1,46 +1,75 @@
 package puppetdb
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
-	"net/url"
+	"strconv"
 	"strings"
 )
 
+// Versions of the commands submitted by this client
+const (
+	ReplaceFactsVersion   = 5
+	ReplaceCatalogVersion = 9
+	StoreReportVersion    = 8
+	DeactivateNodeVersion = 3
+)
+
 /*
 SubmitCommand - Generic command submission support, for submitting commands to a PuppetDB instance.
 
 This is ordinarily not used, instead its recommended to use the various direct
 functions instead.
 
-More detail here: http://docs.puppetlabs.com/puppetdb/latest/api/commands.html
-*/
-func (server *Server) SubmitCommand(command string, version int, payload interface{}) (*CommandResponse, error) {
-	baseURL := server.BaseURL
-	commandsURL := strings.Join([]string{baseURL, "v3/commands"}, "")
+The command, such as 'replace facts', its version and the certname it applies
+to are passed as query parameters and the payload is sent as the JSON body.
 
-	commandObject := CommandObject{command, version, payload}
-	commandJSON, err := json.Marshal(commandObject)
-	if err != nil {
-		return nil, err
-	}
+By default PuppetDB only acknowledges the command is queued. Set
+WaitForCompletion in opts to have it wait until the command is processed, in
+which case the response reports whether it was processed, timed out or
+failed, rather than returning an error. The server's HTTPTimeout is extended
+by the wait for this request.
 
-	data := url.Values{}
-	data.Set("payload", string(commandJSON[:]))
+More detail here: https://puppet.com/docs/puppetdb/latest/api/command/v1/commands.html
+*/
+func (server *Server) SubmitCommand(command string, version int, certname string, payload interface{}, opts *CommandOptions) (*CommandResponse, error) {
+	return server.SubmitCommandContext(context.Background(), command, version, certname, payload, opts)
+}
 
-	req, err := http.NewRequest("POST", commandsURL, bytes.NewBufferString(data.Encode()))
+/*
+SubmitCommandContext - SubmitCommand with a context for cancellation and deadlines.
+*/
+func (server *Server) SubmitCommandContext(ctx context.Context, command string, version int, certname string, payload interface{}, opts *CommandOptions) (*CommandResponse, error) {
+	params := opts.values()
+	params.Set("command", strings.Replace(command, " ", "_", -1))
+	params.Set("version", strconv.Itoa(version))
+	params.Set("certname", certname)
+	commandsURL := server.BaseURL + "pdb/cmd/v1?" + params.Encode()
+
+	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
 
-	client := &http.Client{Transport: server.HTTPTransport}
-	resp, err := client.Do(req)
+	// When waiting, a 503 reports the outcome rather than PuppetDB being
+	// unavailable, so resubmitting would only repeat the work
+	retryable := IdempotentCommands[strings.Replace(command, "_", " ", -1)] && !opts.waiting()
+	resp, err := server.do(withExtraTimeout(ctx, opts.wait()), "POST", commandsURL, payloadJSON, "application/json", retryable)
 	if err != nil {
+		// A command that timed out or failed while waiting is reported as 503
+		var apiErr *APIError
+		if opts.waiting() && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusServiceUnavailable {
+			var commandResponse CommandResponse
+			if json.Unmarshal(apiErr.Body, &commandResponse) == nil && commandResponse.UUID != "" {
+				return &commandResponse, nil
+			}
+		}
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	bodyRC, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -48,8 +77,7 @@ func (server *Server) SubmitCommand(command string, version int, payload interfa
 	}
 
 	var commandResponse CommandResponse
-	err = json.Unmarshal(bodyRC, &commandResponse)
-	if err != nil {
+	if err := decodeResponse(commandsURL, bodyRC, &commandResponse); err != nil {
 		return nil, err
 	}
 
@@ -60,18 +88,31 @@ func (server *Server) SubmitCommand(command string, version int, payload interfa
 ReplaceFacts - Submit a new 'replace facts' command to PuppetDB.
 
 This function will submit a 'replace facts' command. It accepts a certificate
-name and a map of facts (key/value pairs).
+name and a map of facts, each any value that can be marshaled to JSON, produced at the time given in opts,
+or now, in the production environment. Use SubmitCommand with a FactsWireFormat for anything else.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/commands.html#replace-facts-version-1
+More details here: https://puppet.com/docs/puppetdb/latest/api/command/v1/commands.html#replace-facts-version-5
 */
-func (server *Server) ReplaceFacts(certname string, facts map[string]string) (*CommandResponse, error) {
-	factsPayload := FactsWireFormat{certname, facts}
-	factsJSON, err := json.Marshal(factsPayload)
+func (server *Server) ReplaceFacts(certname string, facts map[string]interface{}, opts *CommandOptions) (*CommandResponse, error) {
+	return server.ReplaceFactsContext(context.Background(), certname, facts, opts)
+}
+
+/*
+ReplaceFactsContext - ReplaceFacts with a context for cancellation and deadlines.
+*/
+func (server *Server) ReplaceFactsContext(ctx context.Context, certname string, facts map[string]interface{}, opts *CommandOptions) (*CommandResponse, error) {
+	values, err := NewFacts(facts)
 	if err != nil {
 		return nil, err
 	}
+	factsPayload := FactsWireFormat{
+		Certname:          certname,
+		Environment:       "production",
+		ProducerTimestamp: opts.producerTimestamp(),
+		Values:            values,
+	}
 
-	commandResponse, err := server.SubmitCommand("replace facts", 1, string(factsJSON[:]))
+	commandResponse, err := server.SubmitCommandContext(ctx, "replace facts", ReplaceFactsVersion, certname, factsPayload, opts)
 	return commandResponse, err
 }
 
@@ -81,34 +122,75 @@ DeactivateNode - Submit a new 'deactivate node' command to PuppetDB.
 This function will submit a 'deactivate node' command. It accepts a certificate
 name as an argument to indicate which node to deactivate.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/commands.html#deactivate-node-version-1
+More details here: https://puppet.com/docs/puppetdb/latest/api/command/v1/commands.html#deactivate-node-version-3
 */
-func (server *Server) DeactivateNode(certname string) (*CommandResponse, error) {
-	certnameJSON, err := json.Marshal(certname)
-	if err != nil {
-		return nil, err
-	}
+func (server *Server) DeactivateNode(certname string, opts *CommandOptions) (*CommandResponse, error) {
+	return server.DeactivateNodeContext(context.Background(), certname, opts)
+}
 
-	commandResponse, err := server.SubmitCommand("deactivate node", 1, string(certnameJSON[:]))
+/*
+DeactivateNodeContext - DeactivateNode with a context for cancellation and deadlines.
+*/
+func (server *Server) DeactivateNodeContext(ctx context.Context, certname string, opts *CommandOptions) (*CommandResponse, error) {
+	deactivatePayload := DeactivateNodeWireFormat{certname, opts.producerTimestamp()}
+
+	commandResponse, err := server.SubmitCommandContext(ctx, "deactivate node", DeactivateNodeVersion, certname, deactivatePayload, opts)
 	return commandResponse, err
 }
 
 /*
 ReplaceCatalog - Submit a new 'replace catalog' command to PuppetDB.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/commands.html#replace-catalog-version-3
+The catalog is checked with Validate first, and not submitted if invalid.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/command/v1/commands.html#replace-catalog-version-9
+*/
+func (server *Server) ReplaceCatalog(catalog CatalogWireFormat, opts *CommandOptions) (*CommandResponse, error) {
+	return server.ReplaceCatalogContext(context.Background(), catalog, opts)
+}
+
+/*
+ReplaceCatalogContext - ReplaceCatalog with a context for cancellation and deadlines.
 */
-func (server *Server) ReplaceCatalog(catalog CatalogWireFormat) (*CommandResponse, error) {
-	commandResponse, error := server.SubmitCommand("replace catalog", 3, catalog)
+func (server *Server) ReplaceCatalogContext(ctx context.Context, catalog CatalogWireFormat, opts *CommandOptions) (*CommandResponse, error) {
+	if err := catalog.Validate(); err != nil {
+		return nil, err
+	}
+	if catalog.ProducerTimestamp.IsZero() {
+		catalog.ProducerTimestamp = opts.producerTimestamp()
+	}
+
+	commandResponse, error := server.SubmitCommandContext(ctx, "replace catalog", ReplaceCatalogVersion, catalog.Certname, catalog, opts)
 	return commandResponse, error
 }
 
 /*
 StoreReport - Submit a new 'store report' command to PuppetDB.
 
-More details here: http://docs.puppetlabs.com/puppetdb/1.6/api/commands.html#store-report-version-2
+More details here: https://puppet.com/docs/puppetdb/latest/api/command/v1/commands.html#store-report-version-8
+*/
+func (server *Server) StoreReport(report ReportWireFormat, opts *CommandOptions) (*CommandResponse, error) {
+	return server.StoreReportContext(context.Background(), report, opts)
+}
+
+/*
+StoreReportContext - StoreReport with a context for cancellation and deadlines.
 */
-func (server *Server) StoreReport(report ReportWireFormat) (*CommandResponse, error) {
-	commandResponse, error := server.SubmitCommand("store report", 2, report)
+func (server *Server) StoreReportContext(ctx context.Context, report ReportWireFormat, opts *CommandOptions) (*CommandResponse, error) {
+	if report.ProducerTimestamp.IsZero() {
+		report.ProducerTimestamp = opts.producerTimestamp()
+	}
+	// PuppetDB requires arrays rather than null for these
+	if report.Resources == nil {
+		report.Resources = []ReportResource{}
+	}
+	if report.Metrics == nil {
+		report.Metrics = []Metric{}
+	}
+	if report.Logs == nil {
+		report.Logs = []Log{}
+	}
+
+	commandResponse, error := server.SubmitCommandContext(ctx, "store report", StoreReportVersion, report.Certname, report, opts)
 	return commandResponse, error
 }
@@ -1,6 +1,111 @@
 package puppetdb
 
-import "testing"
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
 
 func TestSqrt(t *testing.T) {
 }
+
+func TestSubmitCommand(t *testing.T) {
+	var request *http.Request
+	var payload map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, payload = r, nil
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"uuid": "abc"}`))
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	resp, err := server.ReplaceFacts("foo.example.com", map[string]interface{}{"kernel": "Linux"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.UUID != "abc" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	params := request.URL.Query()
+	if request.URL.Path != "/pdb/cmd/v1" || params.Get("command") != "replace_facts" ||
+		params.Get("version") != "5" || params.Get("certname") != "foo.example.com" {
+		t.Errorf("unexpected request %s", request.URL)
+	}
+	if request.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected content type %q", request.Header.Get("Content-Type"))
+	}
+	if payload["certname"] != "foo.example.com" || payload["producer_timestamp"] == nil ||
+		payload["values"].(map[string]interface{})["kernel"] != "Linux" {
+		t.Errorf("unexpected payload %v", payload)
+	}
+
+	if _, err := server.DeactivateNode("foo.example.com", nil); err != nil {
+		t.Fatal(err)
+	}
+	params = request.URL.Query()
+	if params.Get("command") != "deactivate_node" || params.Get("version") != "3" {
+		t.Errorf("unexpected request %s", request.URL)
+	}
+	if len(payload) != 2 || payload["certname"] != "foo.example.com" || payload["producer_timestamp"] == nil {
+		t.Errorf("unexpected payload %v", payload)
+	}
+}
+
+func TestSubmitCommandWait(t *testing.T) {
+	var params url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = r.URL.Query()
+		if params.Get("certname") == "held.example.com" {
+			// Hold the request open for longer than the HTTPTimeout
+			time.Sleep(200 * time.Millisecond)
+		}
+		if params.Get("certname") == "slow.example.com" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"uuid": "abc", "timed_out": true}`))
+			return
+		}
+		w.Write([]byte(`{"uuid": "abc", "processed": true}`))
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	produced := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	opts := &CommandOptions{WaitForCompletion: 1500 * time.Millisecond, ProducerTimestamp: produced}
+	resp, err := server.DeactivateNode("foo.example.com", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Processed || resp.TimedOut {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if params.Get("secondsToWaitForCompletion") != "2" || params.Get("producer-timestamp") != "2020-01-02T03:04:05Z" {
+		t.Errorf("unexpected parameters %v", params)
+	}
+
+	resp, err = server.DeactivateNode("slow.example.com", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Processed || !resp.TimedOut {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if _, err := server.DeactivateNode("slow.example.com", nil); !hasStatus(err, http.StatusServiceUnavailable) {
+		t.Errorf("expected a 503 error without waiting, got %v", err)
+	}
+
+	// The wait extends the timeout for the request that asked for it
+	server.SetHTTPTimeout(100 * time.Millisecond)
+	if _, err := server.DeactivateNode("held.example.com", opts); err != nil {
+		t.Errorf("expected the wait to extend the timeout, got %v", err)
+	}
+	if _, err := server.DeactivateNode("held.example.com", nil); err == nil {
+		t.Error("expected a timeout without waiting")
+	}
+}
@@ -1,29 +1,86 @@
 package puppetdb
 
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
 /*
-CommandObject - Top level struct representing a PuppetDB commands payload object.
+DeactivateNodeWireFormat - Payload of the 'deactivate node' command.
 
-See here for more details on the protocol: http://docs.puppetlabs.com/puppetdb/latest/api/commands.html
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/deactivate_node_format_v3.html
 */
-type CommandObject struct {
-	// Command name, such as 'replace facts' or 'deactivate node'
-	Command string `json:"command"`
-	// Command version as an integer
-	Version int `json:"version"`
-	// Command payload, may contain different data types depending on command
-	Payload interface{} `json:"payload"`
+type DeactivateNodeWireFormat struct {
+	// Certificate name of the node to deactivate
+	Certname string `json:"certname"`
+	// Time of the deactivation, later commands for the node reactivate it
+	ProducerTimestamp time.Time `json:"producer_timestamp"`
+}
+
+/*
+CommandOptions - Options for submitting a command, a nil *CommandOptions
+submits asynchronously with the current time as the producer timestamp.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/command/v1/commands.html#command-submission
+*/
+type CommandOptions struct {
+	// Wait up to this long for the command to be processed, rounded up to
+	// whole seconds. 0 returns as soon as it is queued
+	WaitForCompletion time.Duration
+	// Time the data in the command was produced, PuppetDB discards commands
+	// older than ones it has already processed for the node
+	ProducerTimestamp time.Time
+}
+
+func (opts *CommandOptions) values() url.Values {
+	values := url.Values{}
+	if opts.waiting() {
+		values.Set("secondsToWaitForCompletion", strconv.FormatInt(int64(opts.wait()/time.Second), 10))
+	}
+	if opts != nil && !opts.ProducerTimestamp.IsZero() {
+		values.Set("producer-timestamp", opts.ProducerTimestamp.Format(time.RFC3339Nano))
+	}
+	return values
+}
+
+func (opts *CommandOptions) waiting() bool {
+	return opts != nil && opts.WaitForCompletion > 0
+}
+
+// wait returns how long PuppetDB is asked to wait, in whole seconds
+func (opts *CommandOptions) wait() time.Duration {
+	if !opts.waiting() {
+		return 0
+	}
+	return (opts.WaitForCompletion + time.Second - 1) / time.Second * time.Second
+}
+
+func (opts *CommandOptions) producerTimestamp() time.Time {
+	if opts == nil || opts.ProducerTimestamp.IsZero() {
+		return time.Now()
+	}
+	return opts.ProducerTimestamp
 }
 
 /*
 CommandResponse to a commands submission request.
 
 This struct contains the fields that are returned when a command was
-successfully submitted. This does not indicate the command was processed,
-just an acknowledgement it was received and will be processed in the future.
+successfully submitted. Unless the command was submitted with
+WaitForCompletion this does not indicate the command was processed, just an
+acknowledgement it was received and will be processed in the future.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/commands.html#command-submission
+More details here: https://puppet.com/docs/puppetdb/latest/api/command/v1/commands.html#command-submission
 */
 type CommandResponse struct {
 	// A UUID returned by the server uniquely identifying a command submission
 	UUID string `json:"uuid"`
+	// Whether the command was processed, only when waiting for completion
+	Processed bool `json:"processed"`
+	// Whether the wait for completion ran out before the command was processed
+	TimedOut bool `json:"timed_out"`
+	// Why processing the command failed, in which case it has been moved to
+	// the dead letter office
+	Error string `json:"error,omitempty"`
 }
@@ -0,0 +1,119 @@
+package puppetdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/pql"
+)
+
+// maxErrorBody limits how much of an error response is kept in an APIError
+const maxErrorBody = 64 * 1024
+
+/*
+APIError - Returned when PuppetDB, or anything in front of it, answers a
+request with a non-2xx status.
+
+Use errors.As to get at the details, or the IsNotFound, IsUnauthorized and
+IsQueryParseError helpers for the common cases.
+*/
+type APIError struct {
+	// HTTP status code of the response
+	StatusCode int
+	// End-point path the request was made to, such as pdb/query/v4/nodes
+	Endpoint string
+	// Full URL of the request, including the query string
+	URL string
+	// Error message reported by PuppetDB, or the raw response body
+	Message string
+	// Raw response body, truncated to 64KB
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("puppetdb: %s returned %d %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("puppetdb: %s returned %d %s: %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// newAPIError builds an APIError from a failed response, consuming the body
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Endpoint:   strings.TrimPrefix(resp.Request.URL.Path, "/"),
+		URL:        resp.Request.URL.String(),
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	apiErr.Body = body
+	apiErr.Message = strings.TrimSpace(string(body))
+
+	// PuppetDB reports some failures as a JSON document
+	var doc struct {
+		Error string `json:"error"`
+		Msg   string `json:"msg"`
+	}
+	if json.Unmarshal(body, &doc) == nil {
+		if doc.Error != "" {
+			apiErr.Message = doc.Error
+		} else if doc.Msg != "" {
+			apiErr.Message = doc.Msg
+		}
+	}
+	return apiErr
+}
+
+// decodeResponse unmarshals a response body, identifying the end-point on failure
+func decodeResponse(url string, body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("puppetdb: decoding response from %s: %w", url, err)
+	}
+	return nil
+}
+
+func hasStatus(err error, codes ...int) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range codes {
+		if apiErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+IsNotFound - Whether err is a 404 from PuppetDB, such as querying the catalog
+of an unknown node.
+*/
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+/*
+IsUnauthorized - Whether err is PuppetDB rejecting the request's credentials,
+for example an expired RBAC token or a missing client certificate.
+*/
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized, http.StatusForbidden)
+}
+
+/*
+IsQueryParseError - Whether err is a query PuppetDB could not parse, or a PQL
+query rejected by the client-side parser before it was sent.
+*/
+func IsQueryParseError(err error) bool {
+	var syntaxErr *pql.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return true
+	}
+	return hasStatus(err, http.StatusBadRequest)
+}
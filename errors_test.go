@@ -0,0 +1,50 @@
+package puppetdb
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pdb/query/v4/nodes":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"kind": "puppetlabs.rbac/user-unauthenticated", "msg": "Route requires authentication"}`))
+		case "/pdb/query/v4/reports":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Unrecognized operator 'fish'"))
+		case "/pdb/query/v4/events":
+			w.Write([]byte("<html>not json</html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	_, err := server.QueryNodes(nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !IsUnauthorized(err) {
+		t.Fatalf("expected an unauthorized APIError, got %v", err)
+	}
+	if apiErr.Endpoint != "pdb/query/v4/nodes" || apiErr.Message != "Route requires authentication" {
+		t.Errorf("unexpected APIError %+v", apiErr)
+	}
+
+	if _, err := server.QueryReports(nil, nil); !IsQueryParseError(err) || !strings.Contains(err.Error(), "fish") {
+		t.Errorf("expected a query parse error, got %v", err)
+	}
+	if _, err := server.QueryCatalogs("missing"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if _, err := server.QueryEvents(nil, nil); err == nil || IsNotFound(err) {
+		t.Errorf("expected a decode error, got %v", err)
+	}
+	if _, err := server.QueryPQL("nodes {"); !IsQueryParseError(err) {
+		t.Errorf("expected a PQL syntax error to be a query parse error, got %v", err)
+	}
+}
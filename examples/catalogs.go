@@ -2,7 +2,8 @@ package main
 
 import (
 	"fmt"
-	"github.com/kbarber/puppetdb-client-go"
+
+	"github.com/ChrisHirsch/puppetdb-client-go"
 )
 
 func main() {
@@ -10,7 +11,7 @@ func main() {
 
 	// Query catalog
 	catResponse, _ := server.QueryCatalogs("foobar")
-	fmt.Printf("Catalog Name: %v\n", catResponse.Data.Name)
-	fmt.Printf("Catalog Version: %v\n", catResponse.Data.Version)
-	fmt.Printf("Catalog Transaction UUID: %v\n", catResponse.Data.TransactionUuid)
+	fmt.Printf("Catalog Name: %v\n", catResponse.Certname)
+	fmt.Printf("Catalog Version: %v\n", catResponse.Version)
+	fmt.Printf("Catalog Transaction UUID: %v\n", catResponse.TransactionUUID)
 }
@@ -2,11 +2,11 @@ package main
 
 import (
 	"fmt"
-	"github.com/kbarber/puppetdb-client-go"
+	"github.com/ChrisHirsch/puppetdb-client-go"
 )
 
 func main() {
 	server := puppetdb.NewServer("http://localhost:8080/")
-	response, _ := server.DeactivateNode("foobar")
-	fmt.Printf("UUID: %v\n", response.Uuid)
+	response, _ := server.DeactivateNode("foobar", nil)
+	fmt.Printf("UUID: %v\n", response.UUID)
 }
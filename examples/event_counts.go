@@ -2,21 +2,15 @@ package main
 
 import (
 	"fmt"
-	"github.com/kbarber/puppetdb-client-go"
-	"net/url"
+
+	"github.com/ChrisHirsch/puppetdb-client-go"
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
 )
 
 func main() {
 	server := puppetdb.NewServer("http://localhost:8080/")
 
-	// A blank query string - just an example
-        var values url.Values
-        values = map[string][]string{
-                "query":[]string{"[\"=\",\"certname\",\"foobar\"]"},
-		"summarize-by":[]string{"certname"},
-        }
-        queryString := values.Encode()
-
-	response, _ := server.QueryEventCounts(queryString)
+	query := ast.Equals{Field: "certname", Value: "foobar"}
+	response, _ := server.QueryEventCounts(query, "certname", nil)
 	fmt.Printf("Event Counts: %v\n", response)
 }
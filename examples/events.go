@@ -2,20 +2,15 @@ package main
 
 import (
 	"fmt"
-	"github.com/kbarber/puppetdb-client-go"
-	"net/url"
+
+	"github.com/ChrisHirsch/puppetdb-client-go"
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
 )
 
 func main() {
 	server := puppetdb.NewServer("http://localhost:8080/")
 
-	// A blank query string - just an example
-        var values url.Values
-        values = map[string][]string{
-                "query":[]string{"[\"=\",\"certname\",\"foobar\"]"},
-        }
-        queryString := values.Encode()
-
-	response, _ := server.QueryEvents(queryString)
+	query := ast.Equals{Field: "certname", Value: "foobar"}
+	response, _ := server.QueryEvents(query, nil)
 	fmt.Printf("Events: %v\n", response)
 }
@@ -2,23 +2,24 @@ package main
 
 import (
 	"fmt"
-	"github.com/kbarber/puppetdb-client-go"
+
+	"github.com/ChrisHirsch/puppetdb-client-go"
 )
 
 func main() {
 	// Create catalog
-        catalog := puppetdb.NewCatalogWireFormat()
-	catalog.Metadata.ApiVersion = 1
-	catalog.Data.Name = "foobar"
-	catalog.Data.Version = "3"
-	catalog.Data.TransactionUuid = "aaaab"
+	catalog := puppetdb.NewCatalogWireFormat()
+	catalog.Certname = "foobar"
+	catalog.Version = "3"
+	catalog.Environment = "production"
+	catalog.TransactionUUID = "aaaab"
 
 	var e1 puppetdb.CatalogEdge
-	e1.Source = puppetdb.CatalogResourceSpec{"File", "/etc"}
-	e1.Target = puppetdb.CatalogResourceSpec{"File", "/etc/hosts"}
-	e1.Relationship = "required-by"
+	e1.Source = puppetdb.CatalogResourceSpec{Type: "File", Title: "/etc"}
+	e1.Target = puppetdb.CatalogResourceSpec{Type: "File", Title: "/etc/hosts"}
+	e1.Relationship = "before"
 
-	catalog.Data.Edges = []puppetdb.CatalogEdge{e1}
+	catalog.Edges = []puppetdb.CatalogEdge{e1}
 
 	var r1 puppetdb.CatalogResource
 	r1.Type = "File"
@@ -27,21 +28,25 @@ func main() {
 	r1.File = "/etc/puppet/manifests/site.pp"
 	r1.Line = 1
 	r1.Tags = []string{"foo", "bar"}
-	r1.Parameters = map[string]string{"foo":"bar"}
+	r1.Parameters = map[string]interface{}{"ensure": "directory"}
 
 	var r2 puppetdb.CatalogResource
 	r2.Type = "File"
 	r2.Title = "/etc/hosts"
 	r2.Exported = false
 	r2.File = "/etc/puppet/manifests/site.pp"
-	r2.Line = 1
+	r2.Line = 2
 	r2.Tags = []string{"foo", "bar"}
-	r2.Parameters = map[string]string{"foo":"bar"}
+	r2.Parameters = map[string]interface{}{"ensure": "file", "mode": "0644"}
 
-	catalog.Data.Resources = []puppetdb.CatalogResource{r1, r2}
+	catalog.Resources = []puppetdb.CatalogResource{r1, r2}
 
 	// Submit catalog
 	server := puppetdb.NewServer("http://localhost:8080/")
-	response, _ := server.ReplaceCatalog(catalog)
-	fmt.Printf("UUID: %v\n", response.Uuid)
+	response, err := server.ReplaceCatalog(catalog, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("UUID: %v\n", response.UUID)
 }
@@ -2,16 +2,16 @@ package main
 
 import (
 	"fmt"
-	"github.com/kbarber/puppetdb-client-go"
+	"github.com/ChrisHirsch/puppetdb-client-go"
 )
 
 func main() {
 	server := puppetdb.NewServer("http://localhost:8080/")
 
-	facts := map[string]string{
+	facts := map[string]interface{}{
 		"foo": "bar",
 	}
 
-	response, _ := server.ReplaceFacts("foobar", facts)
-	fmt.Printf("UUID: %v\n", response.Uuid)
+	response, _ := server.ReplaceFacts("foobar", facts, nil)
+	fmt.Printf("UUID: %v\n", response.UUID)
 }
@@ -2,20 +2,15 @@ package main
 
 import (
 	"fmt"
-	"github.com/kbarber/puppetdb-client-go"
-	"net/url"
+
+	"github.com/ChrisHirsch/puppetdb-client-go"
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
 )
 
 func main() {
 	server := puppetdb.NewServer("http://localhost:8080/")
 
-	// A blank query string - just an example
-        var values url.Values
-        values = map[string][]string{
-                "query":[]string{""},
-        }
-        queryString := values.Encode()
-
-	response, _ := server.QueryResources(queryString)
+	query := ast.Equals{Field: "type", Value: "Class"}
+	response, _ := server.QueryResources(query, nil)
 	fmt.Printf("Resources: %v\n", response)
 }
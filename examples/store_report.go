@@ -2,38 +2,63 @@ package main
 
 import (
 	"fmt"
-	"github.com/kbarber/puppetdb-client-go"
+	"time"
+
+	"github.com/ChrisHirsch/puppetdb-client-go"
 )
 
 func main() {
+	now := time.Now()
+
 	// Create report
 	var report puppetdb.ReportWireFormat
 
 	report.Certname = "foobar"
-	report.PuppetVersion = "3.1.1"
-	report.ReportFormat = 1
+	report.Environment = "production"
+	report.PuppetVersion = "6.19.1"
+	report.ReportFormat = 10
 	report.ConfigurationVersion = "aaa"
-	report.StartTime = "2013-10-28T12:35:00.000Z"
-	report.EndTime = "2013-10-28T12:35:00.000Z"
-	report.TransactionUuid = "aaa"
+	report.StartTime = now.Add(-time.Minute)
+	report.EndTime = now
+	report.ProducerTimestamp = now
+	report.TransactionUUID = "aaa"
+	report.CachedCatalogStatus = "not_used"
+	report.Status = "changed"
 
 	var e1 puppetdb.ResourceEvent
-	e1.ResourceType = "File"
-	e1.ResourceTitle = "/etc/hosts"
 	e1.Property = "content"
-	e1.Timestamp = "2013-10-28T12:35:00.000Z"
-	e1.Status = "changed"
+	e1.Timestamp = now
+	e1.Status = "success"
 	e1.OldValue = "foo"
 	e1.NewValue = "bar"
 	e1.Message = "foo has now changed to bar"
-	e1.File = "/etc/puppet/manifests/site.pp"
-	e1.Line = 1
-	e1.ContainmentPath = []string{"main"}
 
-	report.ResourceEvents = []puppetdb.ResourceEvent{e1}
+	var r1 puppetdb.ReportResource
+	r1.ResourceType = "File"
+	r1.ResourceTitle = "/etc/hosts"
+	r1.Timestamp = now
+	file, line := "/etc/puppet/manifests/site.pp", 1
+	r1.File = &file
+	r1.Line = &line
+	r1.ContainmentPath = []string{"Stage[main]", "Main", "File[/etc/hosts]"}
+	r1.Events = []puppetdb.ResourceEvent{e1}
+
+	report.Resources = []puppetdb.ReportResource{r1}
+	report.Metrics = []puppetdb.Metric{{Category: "resources", Name: "changed", Value: 1}}
+	report.Logs = []puppetdb.Log{{
+		Level:   "notice",
+		Message: "content changed '{md5}foo' to '{md5}bar'",
+		Source:  "/Stage[main]/Main/File[/etc/hosts]/content",
+		Tags:    []string{"notice", "file"},
+		Time:    now,
+	}}
 
 	// Store report
 	server := puppetdb.NewServer("http://localhost:8080/")
-	response, _ := server.StoreReport(report)
-	fmt.Printf("UUID: %v\n", response.Uuid)
+	response, err := server.StoreReport(report, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("UUID: %v\n", response.UUID)
 }
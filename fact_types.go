@@ -1,14 +1,29 @@
 package puppetdb
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 /*
 FactsWireFormat struct for submitting the 'replace facts' command to PuppetDB.
-More details: https://puppet.com/docs/puppetdb/5.2/api/wire_format/facts_format_v5.html
+More details: https://puppet.com/docs/puppetdb/latest/api/wire_format/facts_format_v5.html
 */
 type FactsWireFormat struct {
 	// Certificate name of node to replace facts for
-	Name string `json:"name"`
-	// A map of fact key/value pairs
-	Values map[string]string `json:"values"`
+	Certname string `json:"certname"`
+	// Environment the facts were collected in
+	Environment string `json:"environment"`
+	// Time the facts were collected, used to discard out of date commands
+	ProducerTimestamp time.Time `json:"producer_timestamp"`
+	// Certificate name of the Puppet Server that sent the facts, if any
+	Producer string `json:"producer,omitempty"`
+	// Facts by name, each any JSON value
+	Values Facts `json:"values"`
 }
 
 /*
@@ -16,8 +31,129 @@ Fact response based query end-points.
 https://puppet.com/docs/puppetdb/5.2/api/query/v4/facts.html#query-fields
 */
 type Fact struct {
-	Certname    string `json:"certname"`
-	Name        string `json:"name"`
-	Value       string `json:"value"`
-	Environment string `json:"pupenv"`
+	Certname    string    `json:"certname"`
+	Name        string    `json:"name"`
+	Value       FactValue `json:"value"`
+	Environment string    `json:"environment"`
+}
+
+/*
+Factset - The whole fact set of a node, as returned by the factsets query
+end-point.
+
+Facts are expanded in line, with a link to query them separately.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/factsets.html#response-format
+*/
+type Factset struct {
+	Certname          string        `json:"certname"`
+	Environment       string        `json:"environment"`
+	Timestamp         time.Time     `json:"timestamp"`
+	ProducerTimestamp time.Time     `json:"producer_timestamp"`
+	Producer          string        `json:"producer"`
+	Hash              string        `json:"hash"`
+	Facts             ExpandedFacts `json:"facts"`
+}
+
+/*
+ExpandedFacts - Facts included in a factset query response, along with the
+URL path to query them on their own.
+*/
+type ExpandedFacts struct {
+	Href string         `json:"href"`
+	Data []FactsetEntry `json:"data"`
+}
+
+/*
+FactsetEntry - A single fact within a factset.
+*/
+type FactsetEntry struct {
+	Name  string    `json:"name"`
+	Value FactValue `json:"value"`
+}
+
+/*
+Values - The facts of the set by name, for looking up paths such as
+os.release.major with Get.
+*/
+func (f Factset) Values() Facts {
+	facts := make(Facts, len(f.Facts.Data))
+	for _, fact := range f.Facts.Data {
+		facts[fact.Name] = fact.Value
+	}
+	return facts
+}
+
+/*
+FactPath - The path to a value within a structured fact, starting with the
+fact name. Elements are hash keys as strings and array indexes as ints.
+*/
+type FactPath []interface{}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding array indexes as ints
+func (p *FactPath) UnmarshalJSON(data []byte) error {
+	var elements []interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&elements); err != nil {
+		return err
+	}
+	path := make(FactPath, len(elements))
+	for i, element := range elements {
+		switch e := element.(type) {
+		case string:
+			path[i] = e
+		case json.Number:
+			index, err := strconv.Atoi(e.String())
+			if err != nil {
+				return fmt.Errorf("puppetdb: invalid fact path index %s", e)
+			}
+			path[i] = index
+		default:
+			return fmt.Errorf("puppetdb: invalid fact path element %v", element)
+		}
+	}
+	*p = path
+	return nil
+}
+
+// Strings returns the elements of the path as strings, as taken by Facts.Path
+func (p FactPath) Strings() []string {
+	elements := make([]string, len(p))
+	for i, element := range p {
+		elements[i] = fmt.Sprint(element)
+	}
+	return elements
+}
+
+// String formats the path dotted, such as os.release.major
+func (p FactPath) String() string {
+	return strings.Join(p.Strings(), ".")
+}
+
+/*
+FactContent - A single value within the facts of a node, as returned by the
+fact-contents query end-point. Structured facts are flattened to a row per
+leaf value.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/fact-contents.html#response-format
+*/
+type FactContent struct {
+	Certname    string    `json:"certname"`
+	Environment string    `json:"environment"`
+	Name        string    `json:"name"`
+	Path        FactPath  `json:"path"`
+	Value       FactValue `json:"value"`
+}
+
+/*
+FactPathType - A fact path known to PuppetDB and the type of the values found
+at it, as returned by the fact-paths query end-point.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/fact-paths.html#response-format
+*/
+type FactPathType struct {
+	Path FactPath `json:"path"`
+	// One of string, integer, float, boolean, null, map or array
+	Type string `json:"type"`
 }
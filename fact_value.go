@@ -0,0 +1,249 @@
+package puppetdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoFactValue is returned when a path doesn't lead to a fact value
+var ErrNoFactValue = errors.New("puppetdb: no fact value")
+
+/*
+FactValue - The value of a fact, which may be any JSON value: a string,
+number, boolean, array, hash or null.
+
+Structured facts such as os or networking are walked with Get or Path, and
+read with the As* accessors, which return an error rather than panicking when
+the value is of another type.
+*/
+type FactValue struct {
+	value interface{}
+}
+
+/*
+NewFactValue - Create a FactValue from any value that can be marshaled to
+JSON, such as a string or a map[string]interface{} of a structured fact.
+*/
+func NewFactValue(v interface{}) (FactValue, error) {
+	if f, ok := v.(FactValue); ok {
+		return f, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return FactValue{}, err
+	}
+	var f FactValue
+	err = f.UnmarshalJSON(data)
+	return f, err
+}
+
+// MarshalJSON implements json.Marshaler
+func (f FactValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping numbers exact
+func (f *FactValue) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(&f.value)
+}
+
+// Interface returns the value as decoded by encoding/json, with numbers as json.Number
+func (f FactValue) Interface() interface{} {
+	return f.value
+}
+
+// IsNull reports whether the value is JSON null
+func (f FactValue) IsNull() bool {
+	return f.value == nil
+}
+
+// String formats the value as JSON, or as is for a string
+func (f FactValue) String() string {
+	if s, ok := f.value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(f.value)
+	return string(data)
+}
+
+/*
+Get - Walk a dotted path, such as release.major within the os fact, through
+hashes by key and arrays by index.
+
+Use Path when a key itself contains a dot.
+*/
+func (f FactValue) Get(path string) (FactValue, error) {
+	return f.Path(strings.Split(path, ".")...)
+}
+
+/*
+Path - Walk a path through hashes by key and arrays by index, returning an
+error wrapping ErrNoFactValue when there is nothing at the path.
+*/
+func (f FactValue) Path(path ...string) (FactValue, error) {
+	value := f.value
+	for i, key := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return FactValue{}, fmt.Errorf("%w at %s", ErrNoFactValue, strings.Join(path[:i+1], "."))
+			}
+			value = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return FactValue{}, fmt.Errorf("%w at %s", ErrNoFactValue, strings.Join(path[:i+1], "."))
+			}
+			value = v[index]
+		default:
+			return FactValue{}, fmt.Errorf("%w at %s, %s is %s", ErrNoFactValue,
+				strings.Join(path[:i+1], "."), strings.Join(path[:i], "."), kindOf(value))
+		}
+	}
+	return FactValue{value}, nil
+}
+
+// AsString returns a string value
+func (f FactValue) AsString() (string, error) {
+	s, ok := f.value.(string)
+	if !ok {
+		return "", f.typeError("a string")
+	}
+	return s, nil
+}
+
+// AsInt returns an integer value
+func (f FactValue) AsInt() (int64, error) {
+	n, ok := f.value.(json.Number)
+	if !ok {
+		return 0, f.typeError("an integer")
+	}
+	i, err := n.Int64()
+	if err != nil {
+		return 0, f.typeError("an integer")
+	}
+	return i, nil
+}
+
+// AsFloat returns any numeric value
+func (f FactValue) AsFloat() (float64, error) {
+	n, ok := f.value.(json.Number)
+	if !ok {
+		return 0, f.typeError("a number")
+	}
+	return n.Float64()
+}
+
+// AsBool returns a boolean value
+func (f FactValue) AsBool() (bool, error) {
+	b, ok := f.value.(bool)
+	if !ok {
+		return false, f.typeError("a boolean")
+	}
+	return b, nil
+}
+
+// AsMap returns the entries of a hash value
+func (f FactValue) AsMap() (map[string]FactValue, error) {
+	m, ok := f.value.(map[string]interface{})
+	if !ok {
+		return nil, f.typeError("a hash")
+	}
+	values := make(map[string]FactValue, len(m))
+	for key, value := range m {
+		values[key] = FactValue{value}
+	}
+	return values, nil
+}
+
+// AsArray returns the elements of an array value
+func (f FactValue) AsArray() ([]FactValue, error) {
+	a, ok := f.value.([]interface{})
+	if !ok {
+		return nil, f.typeError("an array")
+	}
+	values := make([]FactValue, len(a))
+	for i, value := range a {
+		values[i] = FactValue{value}
+	}
+	return values, nil
+}
+
+func (f FactValue) typeError(want string) error {
+	return fmt.Errorf("puppetdb: fact value is %s, not %s", kindOf(f.value), want)
+}
+
+func kindOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "an integer"
+		}
+		return "a number"
+	case map[string]interface{}:
+		return "a hash"
+	case []interface{}:
+		return "an array"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+/*
+Facts - A set of facts by name, as submitted with replace facts or returned
+in inventory.
+*/
+type Facts map[string]FactValue
+
+/*
+NewFacts - Create Facts from values that can be marshaled to JSON.
+*/
+func NewFacts(values map[string]interface{}) (Facts, error) {
+	facts := make(Facts, len(values))
+	for name, value := range values {
+		f, err := NewFactValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("fact %s: %w", name, err)
+		}
+		facts[name] = f
+	}
+	return facts, nil
+}
+
+/*
+Get - Look up a dotted path starting with the fact name, such as
+os.release.major.
+*/
+func (facts Facts) Get(path string) (FactValue, error) {
+	return facts.Path(strings.Split(path, ".")...)
+}
+
+/*
+Path - Look up a path starting with the fact name, for keys containing dots.
+*/
+func (facts Facts) Path(path ...string) (FactValue, error) {
+	if len(path) == 0 {
+		return FactValue{}, ErrNoFactValue
+	}
+	fact, ok := facts[path[0]]
+	if !ok {
+		return FactValue{}, fmt.Errorf("%w at %s", ErrNoFactValue, path[0])
+	}
+	value, err := fact.Path(path[1:]...)
+	if err != nil {
+		return FactValue{}, fmt.Errorf("fact %s: %w", path[0], err)
+	}
+	return value, nil
+}
@@ -0,0 +1,70 @@
+package puppetdb
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFactValue(t *testing.T) {
+	var facts []Fact
+	err := json.Unmarshal([]byte(`[
+		{"certname": "foo", "environment": "production", "name": "os", "value": {"family": "RedHat", "release": {"major": "8", "full": "8.4"}, "selinux": {"enabled": true}}},
+		{"certname": "foo", "name": "processorcount", "value": 4},
+		{"certname": "foo", "name": "disks", "value": [{"size_bytes": 21474836480}]}
+	]`), &facts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if facts[0].Environment != "production" {
+		t.Errorf("Environment = %q, want production", facts[0].Environment)
+	}
+	if major, err := facts[0].Value.Get("release.major"); err != nil || major.String() != "8" {
+		t.Errorf("release.major = %v, %v", major, err)
+	}
+	if enabled, err := facts[0].Value.Path("selinux", "enabled"); err != nil {
+		t.Error(err)
+	} else if b, err := enabled.AsBool(); err != nil || !b {
+		t.Errorf("AsBool() = %v, %v", b, err)
+	}
+	if os, err := facts[0].Value.AsMap(); err != nil || len(os) != 3 {
+		t.Errorf("AsMap() = %v, %v", os, err)
+	}
+	if _, err := facts[0].Value.Get("release.minor"); !errors.Is(err, ErrNoFactValue) {
+		t.Errorf("expected ErrNoFactValue, got %v", err)
+	}
+	if _, err := facts[0].Value.Get("family.name"); !errors.Is(err, ErrNoFactValue) {
+		t.Errorf("expected ErrNoFactValue, got %v", err)
+	}
+
+	if n, err := facts[1].Value.AsInt(); err != nil || n != 4 {
+		t.Errorf("AsInt() = %v, %v", n, err)
+	}
+	if _, err := facts[1].Value.AsString(); err == nil {
+		t.Error("expected an error reading an integer as a string")
+	}
+	if size, err := facts[2].Value.Get("0.size_bytes"); err != nil {
+		t.Error(err)
+	} else if n, err := size.AsInt(); err != nil || n != 21474836480 {
+		t.Errorf("AsInt() = %v, %v", n, err)
+	}
+
+	values, err := NewFacts(map[string]interface{}{
+		"kernel": "Linux",
+		"os":     map[string]interface{}{"release": map[string]string{"major": "8"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if major, err := values.Get("os.release.major"); err != nil || major.String() != "8" {
+		t.Errorf("os.release.major = %v, %v", major, err)
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"kernel":"Linux","os":{"release":{"major":"8"}}}` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+}
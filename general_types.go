@@ -17,3 +17,23 @@ More details here: http://docs.puppetlabs.com/puppetdb/latest/api/query/v3/versi
 type Version struct {
 	Version string `json:"version"`
 }
+
+/*
+Environment - An environment known to PuppetDB, as returned by the
+environments query end-point.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/environments.html#response-format
+*/
+type Environment struct {
+	Name string `json:"name"`
+}
+
+/*
+Producer - A Puppet Server that has submitted data to PuppetDB, as returned
+by the producers query end-point.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/producers.html#response-format
+*/
+type Producer struct {
+	Name string `json:"name"`
+}
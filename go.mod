@@ -1,8 +1,14 @@
 module github.com/ChrisHirsch/puppetdb-client-go
 
-go 1.14
+go 1.19
 
 require (
 	github.com/kbarber/puppetdb-client-go v0.0.0-20140120012024-9d3411f6b6b4
 	github.com/sirupsen/logrus v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
+	golang.org/x/sys v0.0.0-20190422165155-953cdadca894 // indirect
 )
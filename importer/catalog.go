@@ -0,0 +1,231 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	puppetdb "github.com/ChrisHirsch/puppetdb-client-go"
+)
+
+/*
+RelationshipMetaparameters - The relationship of the edge each metaparameter
+creates, as PuppetDB names them.
+
+require and subscribe point from the referenced resource to the one they are
+set on, before and notify the other way.
+*/
+var RelationshipMetaparameters = map[string]string{
+	"before":    "before",
+	"require":   "required-by",
+	"notify":    "notifies",
+	"subscribe": "subscription-of",
+}
+
+type jsonCatalog struct {
+	Name        string         `json:"name"`
+	Version     interface{}    `json:"version"`
+	Environment string         `json:"environment"`
+	CodeID      *string        `json:"code_id"`
+	CatalogUUID string         `json:"catalog_uuid"`
+	Resources   []jsonResource `json:"resources"`
+	Edges       []jsonEdge     `json:"edges"`
+	// Older Puppet versions wrap the catalog in a document
+	Data *jsonCatalog `json:"data"`
+}
+
+type jsonResource struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Tags       []string               `json:"tags"`
+	File       string                 `json:"file"`
+	Line       int                    `json:"line"`
+	Exported   bool                   `json:"exported"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+type jsonEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+/*
+ParseResourceReference - Split a Puppet resource reference, such as
+File[/etc/hosts], into its type and title.
+
+Types are capitalized as Puppet does, so file[/etc/hosts] and
+foo::bar[baz] become File[/etc/hosts] and Foo::Bar[baz].
+*/
+func ParseResourceReference(reference string) (puppetdb.CatalogResourceSpec, error) {
+	open := strings.Index(reference, "[")
+	if open <= 0 || !strings.HasSuffix(reference, "]") {
+		return puppetdb.CatalogResourceSpec{}, fmt.Errorf("invalid resource reference %q", reference)
+	}
+	return puppetdb.CatalogResourceSpec{
+		Type:  capitalizeType(reference[:open]),
+		Title: reference[open+1 : len(reference)-1],
+	}, nil
+}
+
+func capitalizeType(name string) string {
+	segments := strings.Split(name, "::")
+	for i, segment := range segments {
+		if segment != "" {
+			segments[i] = strings.ToUpper(segment[:1]) + segment[1:]
+		}
+	}
+	return strings.Join(segments, "::")
+}
+
+/*
+ReadCatalogFile - Read a catalog compiled with puppet catalog compile or
+puppet master --compile, see ParseCatalog.
+*/
+func ReadCatalogFile(path string) (puppetdb.CatalogWireFormat, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return puppetdb.CatalogWireFormat{}, err
+	}
+	catalog, err := ParseCatalog(data)
+	if err != nil {
+		return puppetdb.CatalogWireFormat{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+/*
+ParseCatalog - Convert a catalog in Puppet's native JSON format into the
+catalog wire format, ready for ReplaceCatalog.
+
+Containment edges become contains relationships, and the require, before,
+notify and subscribe metaparameters of each resource become edges as listed
+in RelationshipMetaparameters. Metaparameters may refer to a resource by its
+alias. The result is checked with Validate.
+
+Any output Puppet prints before the JSON document, as puppet master --compile
+does, is skipped. The producer timestamp is left for ReplaceCatalog to set.
+*/
+func ParseCatalog(data []byte) (puppetdb.CatalogWireFormat, error) {
+	if i := bytes.IndexByte(data, '{'); i > 0 {
+		data = data[i:]
+	}
+	// Keep numbers exact, such as a version of seconds since the epoch
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var in jsonCatalog
+	if err := dec.Decode(&in); err != nil {
+		return puppetdb.CatalogWireFormat{}, err
+	}
+	if in.Data != nil {
+		in = *in.Data
+	}
+	if in.Name == "" {
+		return puppetdb.CatalogWireFormat{}, fmt.Errorf("catalog has no name")
+	}
+
+	catalog := puppetdb.CatalogWireFormat{
+		Certname:    in.Name,
+		Environment: in.Environment,
+		CodeID:      in.CodeID,
+		CatalogUUID: in.CatalogUUID,
+		Edges:       []puppetdb.CatalogEdge{},
+		Resources:   make([]puppetdb.CatalogResource, 0, len(in.Resources)),
+	}
+	if in.Version != nil {
+		catalog.Version = fmt.Sprint(in.Version)
+	}
+
+	// Resources may be referred to by title or alias
+	aliases := make(map[puppetdb.CatalogResourceSpec]puppetdb.CatalogResourceSpec)
+	for _, r := range in.Resources {
+		resource := puppetdb.CatalogResource{
+			Type:       r.Type,
+			Title:      r.Title,
+			Aliases:    stringList(r.Parameters["alias"]),
+			Exported:   r.Exported,
+			File:       r.File,
+			Line:       r.Line,
+			Tags:       nonNil(r.Tags),
+			Parameters: r.Parameters,
+		}
+		if resource.Parameters == nil {
+			resource.Parameters = map[string]interface{}{}
+		}
+		for _, alias := range resource.Aliases {
+			aliases[puppetdb.CatalogResourceSpec{Type: r.Type, Title: alias}] = resource.Spec()
+		}
+		catalog.Resources = append(catalog.Resources, resource)
+	}
+	resolve := func(reference string) (puppetdb.CatalogResourceSpec, error) {
+		spec, err := ParseResourceReference(reference)
+		if err != nil {
+			return spec, err
+		}
+		if resolved, ok := aliases[spec]; ok {
+			return resolved, nil
+		}
+		return spec, nil
+	}
+
+	seen := make(map[puppetdb.CatalogEdge]bool)
+	addEdge := func(edge puppetdb.CatalogEdge) {
+		if !seen[edge] {
+			seen[edge] = true
+			catalog.Edges = append(catalog.Edges, edge)
+		}
+	}
+
+	for _, e := range in.Edges {
+		source, err := resolve(e.Source)
+		if err != nil {
+			return puppetdb.CatalogWireFormat{}, err
+		}
+		target, err := resolve(e.Target)
+		if err != nil {
+			return puppetdb.CatalogWireFormat{}, err
+		}
+		addEdge(puppetdb.CatalogEdge{Source: source, Target: target, Relationship: "contains"})
+	}
+
+	for _, resource := range catalog.Resources {
+		for _, metaparameter := range []string{"before", "require", "notify", "subscribe"} {
+			for _, reference := range stringList(resource.Parameters[metaparameter]) {
+				other, err := resolve(reference)
+				if err != nil {
+					return puppetdb.CatalogWireFormat{}, fmt.Errorf("%s %s: %w", resource.Spec(), metaparameter, err)
+				}
+				edge := puppetdb.CatalogEdge{
+					Source:       resource.Spec(),
+					Target:       other,
+					Relationship: RelationshipMetaparameters[metaparameter],
+				}
+				if metaparameter == "require" || metaparameter == "subscribe" {
+					edge.Source, edge.Target = other, resource.Spec()
+				}
+				addEdge(edge)
+			}
+		}
+	}
+
+	if err := catalog.Validate(); err != nil {
+		return puppetdb.CatalogWireFormat{}, err
+	}
+	return catalog, nil
+}
+
+// stringList reads a parameter holding a string or an array of strings
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, element := range v {
+			values = append(values, stringList(element)...)
+		}
+		return values
+	}
+	return nil
+}
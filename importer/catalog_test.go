@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	puppetdb "github.com/ChrisHirsch/puppetdb-client-go"
+)
+
+func TestParseResourceReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      puppetdb.CatalogResourceSpec
+	}{
+		{"File[/etc/hosts]", puppetdb.CatalogResourceSpec{Type: "File", Title: "/etc/hosts"}},
+		{"foo::bar[baz]", puppetdb.CatalogResourceSpec{Type: "Foo::Bar", Title: "baz"}},
+		{"Exec[echo [x]]", puppetdb.CatalogResourceSpec{Type: "Exec", Title: "echo [x]"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseResourceReference(tt.reference)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseResourceReference(%q) = %v, %v, want %v", tt.reference, got, err, tt.want)
+		}
+	}
+	for _, invalid := range []string{"", "File", "[x]", "File[x"} {
+		if _, err := ParseResourceReference(invalid); err == nil {
+			t.Errorf("ParseResourceReference(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestReadCatalogFile(t *testing.T) {
+	catalog, err := ReadCatalogFile("testdata/catalog.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if catalog.Certname != "foo.example.com" || catalog.Version != "1600000000" || len(catalog.Resources) != 6 {
+		t.Errorf("unexpected catalog %+v", catalog)
+	}
+
+	edges := make(map[string]bool)
+	for _, edge := range catalog.Edges {
+		edges[edge.Source.String()+" "+edge.Relationship+" "+edge.Target.String()] = true
+	}
+	for _, want := range []string{
+		"Stage[main] contains Class[Settings]",
+		"Class[main] contains File[/etc/hosts]",
+		"File[/etc] required-by File[/etc/hosts]",
+		"File[/etc/hosts] notifies Service[nscd]",
+	} {
+		if !edges[want] {
+			t.Errorf("missing edge %s", want)
+		}
+	}
+	if len(catalog.Edges) != 7 {
+		t.Errorf("got %d edges, want 7", len(catalog.Edges))
+	}
+}
+
+func TestParseCatalogMissingResource(t *testing.T) {
+	_, err := ParseCatalog([]byte(`{"name": "foo", "resources": [
+		{"type": "File", "title": "/etc/hosts", "parameters": {"require": "Package[hosts]"}}
+	]}`))
+	if err == nil || !strings.Contains(err.Error(), "Package[hosts]") {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+/*
+Package importer converts the files Puppet writes to disk into the wire
+formats of the puppetdb package, so they can be replayed or backfilled into
+PuppetDB with StoreReport and ReplaceCatalog.
+*/
+package importer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	puppetdb "github.com/ChrisHirsch/puppetdb-client-go"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLastRunReport is where the Puppet agent writes the report of its last run
+const DefaultLastRunReport = "/opt/puppetlabs/puppet/cache/state/last_run_report.yaml"
+
+// reportTag is the Ruby class tag Puppet gives the report document
+const reportTag = "!ruby/object:Puppet::Transaction::Report"
+
+// Times are written by Ruby either as ISO 8601 strings or in its own format
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02 15:04:05.999999999 Z",
+}
+
+type yamlReport struct {
+	Host                 string                        `yaml:"host"`
+	Time                 string                        `yaml:"time"`
+	ReportFormat         int                           `yaml:"report_format"`
+	PuppetVersion        string                        `yaml:"puppet_version"`
+	ConfigurationVersion string                        `yaml:"configuration_version"`
+	TransactionUUID      string                        `yaml:"transaction_uuid"`
+	CatalogUUID          string                        `yaml:"catalog_uuid"`
+	CodeID               *string                       `yaml:"code_id"`
+	JobID                string                        `yaml:"job_id"`
+	CachedCatalogStatus  string                        `yaml:"cached_catalog_status"`
+	Environment          string                        `yaml:"environment"`
+	Status               string                        `yaml:"status"`
+	Noop                 bool                          `yaml:"noop"`
+	NoopPending          bool                          `yaml:"noop_pending"`
+	CorrectiveChange     bool                          `yaml:"corrective_change"`
+	ServerUsed           string                        `yaml:"server_used"`
+	MasterUsed           string                        `yaml:"master_used"`
+	Logs                 []yamlLog                     `yaml:"logs"`
+	Metrics              map[string]yamlMetric         `yaml:"metrics"`
+	ResourceStatuses     map[string]yamlResourceStatus `yaml:"resource_statuses"`
+}
+
+type yamlLog struct {
+	Level   string   `yaml:"level"`
+	Message string   `yaml:"message"`
+	Source  string   `yaml:"source"`
+	Tags    []string `yaml:"tags"`
+	Time    string   `yaml:"time"`
+	File    *string  `yaml:"file"`
+	Line    *int     `yaml:"line"`
+}
+
+type yamlMetric struct {
+	Name string `yaml:"name"`
+	// Each value is a [name, label, value] triple
+	Values [][]interface{} `yaml:"values"`
+}
+
+type yamlResourceStatus struct {
+	ResourceType     string      `yaml:"resource_type"`
+	Title            string      `yaml:"title"`
+	File             *string     `yaml:"file"`
+	Line             *int        `yaml:"line"`
+	Time             string      `yaml:"time"`
+	Skipped          bool        `yaml:"skipped"`
+	ContainmentPath  []string    `yaml:"containment_path"`
+	CorrectiveChange bool        `yaml:"corrective_change"`
+	Events           []yamlEvent `yaml:"events"`
+}
+
+type yamlEvent struct {
+	Name             *string     `yaml:"name"`
+	Property         string      `yaml:"property"`
+	PreviousValue    interface{} `yaml:"previous_value"`
+	DesiredValue     interface{} `yaml:"desired_value"`
+	Message          string      `yaml:"message"`
+	Status           string      `yaml:"status"`
+	Time             string      `yaml:"time"`
+	CorrectiveChange bool        `yaml:"corrective_change"`
+}
+
+/*
+ReadReportFile - Read a report written by the Puppet agent, such as
+DefaultLastRunReport, see ParseReport.
+*/
+func ReadReportFile(path string) (puppetdb.ReportWireFormat, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return puppetdb.ReportWireFormat{}, err
+	}
+	report, err := ParseReport(data)
+	if err != nil {
+		return puppetdb.ReportWireFormat{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return report, nil
+}
+
+/*
+ParseReport - Convert a YAML report, tagged !ruby/object:Puppet::Transaction::Report,
+into the report wire format.
+
+The conversion follows the PuppetDB report processor: the run ends after the
+total of the time metrics, which is also used as the producer timestamp, and
+the producer is the Puppet Server the agent used.
+*/
+func ParseReport(data []byte) (puppetdb.ReportWireFormat, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return puppetdb.ReportWireFormat{}, err
+	}
+	if len(doc.Content) == 0 {
+		return puppetdb.ReportWireFormat{}, fmt.Errorf("empty report")
+	}
+	if tag := doc.Content[0].Tag; tag != reportTag {
+		return puppetdb.ReportWireFormat{}, fmt.Errorf("expected a %s document, found %s", reportTag, tag)
+	}
+
+	var in yamlReport
+	if err := doc.Content[0].Decode(&in); err != nil {
+		return puppetdb.ReportWireFormat{}, err
+	}
+	if in.Host == "" {
+		return puppetdb.ReportWireFormat{}, fmt.Errorf("report has no host")
+	}
+
+	start, err := parseTime(in.Time)
+	if err != nil {
+		return puppetdb.ReportWireFormat{}, err
+	}
+
+	report := puppetdb.ReportWireFormat{
+		Certname:             in.Host,
+		Environment:          in.Environment,
+		PuppetVersion:        in.PuppetVersion,
+		ReportFormat:         in.ReportFormat,
+		ConfigurationVersion: in.ConfigurationVersion,
+		StartTime:            start,
+		Producer:             producer(in),
+		TransactionUUID:      in.TransactionUUID,
+		CatalogUUID:          in.CatalogUUID,
+		CodeID:               in.CodeID,
+		JobID:                in.JobID,
+		CachedCatalogStatus:  in.CachedCatalogStatus,
+		Status:               in.Status,
+		Noop:                 in.Noop,
+		NoopPending:          in.NoopPending,
+		CorrectiveChange:     in.CorrectiveChange,
+		Resources:            []puppetdb.ReportResource{},
+		Metrics:              []puppetdb.Metric{},
+		Logs:                 []puppetdb.Log{},
+	}
+
+	// Sort for a stable result, since the YAML holds these as maps
+	categories := make([]string, 0, len(in.Metrics))
+	for category := range in.Metrics {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	var runTime float64
+	for _, category := range categories {
+		for _, value := range in.Metrics[category].Values {
+			metric, err := convertMetric(category, value)
+			if err != nil {
+				return puppetdb.ReportWireFormat{}, err
+			}
+			if category == "time" && metric.Name == "total" {
+				runTime = metric.Value
+			}
+			report.Metrics = append(report.Metrics, metric)
+		}
+	}
+	report.EndTime = start.Add(time.Duration(runTime * float64(time.Second)))
+	report.ProducerTimestamp = report.EndTime
+
+	for _, l := range in.Logs {
+		t, err := parseTime(l.Time)
+		if err != nil {
+			return puppetdb.ReportWireFormat{}, err
+		}
+		report.Logs = append(report.Logs, puppetdb.Log{
+			File:    l.File,
+			Line:    l.Line,
+			Level:   strings.TrimPrefix(l.Level, ":"),
+			Message: l.Message,
+			Source:  l.Source,
+			Tags:    nonNil(l.Tags),
+			Time:    t,
+		})
+	}
+
+	references := make([]string, 0, len(in.ResourceStatuses))
+	for reference := range in.ResourceStatuses {
+		references = append(references, reference)
+	}
+	sort.Strings(references)
+	for _, reference := range references {
+		resource, err := convertResourceStatus(in.ResourceStatuses[reference])
+		if err != nil {
+			return puppetdb.ReportWireFormat{}, fmt.Errorf("%s: %w", reference, err)
+		}
+		report.Resources = append(report.Resources, resource)
+	}
+
+	return report, nil
+}
+
+func convertMetric(category string, value []interface{}) (puppetdb.Metric, error) {
+	if len(value) != 3 {
+		return puppetdb.Metric{}, fmt.Errorf("malformed %s metric %v", category, value)
+	}
+	metric := puppetdb.Metric{Category: category, Name: fmt.Sprint(value[0])}
+	switch v := value[2].(type) {
+	case int:
+		metric.Value = float64(v)
+	case float64:
+		metric.Value = v
+	default:
+		return puppetdb.Metric{}, fmt.Errorf("%s metric %s is not a number: %v", category, metric.Name, value[2])
+	}
+	return metric, nil
+}
+
+func convertResourceStatus(status yamlResourceStatus) (puppetdb.ReportResource, error) {
+	t, err := parseTime(status.Time)
+	if err != nil {
+		return puppetdb.ReportResource{}, err
+	}
+	resource := puppetdb.ReportResource{
+		ResourceType:     status.ResourceType,
+		ResourceTitle:    status.Title,
+		Skipped:          status.Skipped,
+		Timestamp:        t,
+		File:             status.File,
+		Line:             status.Line,
+		ContainmentPath:  nonNil(status.ContainmentPath),
+		CorrectiveChange: status.CorrectiveChange,
+		Events:           []puppetdb.ResourceEvent{},
+	}
+	for _, e := range status.Events {
+		t, err := parseTime(e.Time)
+		if err != nil {
+			return puppetdb.ReportResource{}, err
+		}
+		resource.Events = append(resource.Events, puppetdb.ResourceEvent{
+			Status:           e.Status,
+			Timestamp:        t,
+			Name:             trimSymbol(e.Name),
+			Property:         e.Property,
+			OldValue:         e.PreviousValue,
+			NewValue:         e.DesiredValue,
+			Message:          e.Message,
+			CorrectiveChange: e.CorrectiveChange,
+		})
+	}
+	return resource, nil
+}
+
+// producer returns the certname of the Puppet Server the agent used, if known
+func producer(in yamlReport) *string {
+	server := in.ServerUsed
+	if server == "" {
+		server = in.MasterUsed
+	}
+	if i := strings.LastIndex(server, ":"); i >= 0 {
+		server = server[:i]
+	}
+	if server == "" {
+		return nil
+	}
+	return &server
+}
+
+// trimSymbol drops the colon Ruby writes before a symbol, such as :ensure
+func trimSymbol(name *string) *string {
+	if name == nil {
+		return nil
+	}
+	trimmed := strings.TrimPrefix(*name, ":")
+	return &trimmed
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q", value)
+}
+
+// nonNil avoids null in the wire format, where PuppetDB requires arrays
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
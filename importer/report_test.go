@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadReportFile(t *testing.T) {
+	report, err := ReadReportFile("testdata/last_run_report.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Certname != "foo.example.com" || report.ConfigurationVersion != "1600000000" ||
+		report.ReportFormat != 10 || report.Status != "changed" || report.Producer == nil || *report.Producer != "puppet.example.com" {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.CodeID != nil {
+		t.Errorf("CodeID = %q, want nil", *report.CodeID)
+	}
+	if want := time.Date(2020, 9, 13, 12, 26, 40, 500000000, time.UTC); !report.EndTime.Equal(want) {
+		t.Errorf("EndTime = %s, want %s", report.EndTime, want)
+	}
+
+	if len(report.Metrics) != 4 {
+		t.Fatalf("got %d metrics, want 4", len(report.Metrics))
+	}
+	if m := report.Metrics[0]; m.Category != "resources" || m.Name != "total" || m.Value != 2 {
+		t.Errorf("unexpected metric %+v", m)
+	}
+
+	if len(report.Logs) != 2 || report.Logs[0].Level != "notice" || report.Logs[0].Line == nil || *report.Logs[0].Line != 3 ||
+		report.Logs[1].Line != nil || report.Logs[1].File != nil || report.Logs[1].Time.IsZero() {
+		t.Errorf("unexpected logs %+v", report.Logs)
+	}
+
+	if len(report.Resources) != 2 {
+		t.Fatalf("got %d resources, want 2", len(report.Resources))
+	}
+	file := report.Resources[0]
+	if file.ResourceType != "File" || file.ResourceTitle != "/etc/hosts" || len(file.Events) != 1 {
+		t.Fatalf("unexpected resource %+v", file)
+	}
+	if e := file.Events[0]; e.Name == nil || *e.Name != "mode_changed" || e.OldValue != "0600" || e.NewValue != "0644" || e.Status != "success" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if notify := report.Resources[1]; !notify.Skipped || notify.Events == nil {
+		t.Errorf("unexpected resource %+v", notify)
+	}
+}
+
+func TestParseReportRejectsOtherDocuments(t *testing.T) {
+	if _, err := ParseReport([]byte("--- !ruby/object:Puppet::Resource::Catalog\nname: foo\n")); err == nil {
+		t.Error("expected an error for a catalog document")
+	}
+}
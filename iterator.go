@@ -0,0 +1,299 @@
+package puppetdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+iterator - Decodes a JSON array response one element at a time.
+
+The typed iterators below wrap this so that large result sets never have to
+be held in memory all at once.
+
+The server's HTTPTimeout only bounds the wait for each attempt's response to
+begin, so a long stream isn't cut off part way through. Use the context to
+limit the time spent reading it.
+*/
+type iterator struct {
+	body io.ReadCloser
+	dec  *json.Decoder
+	err  error
+	done bool
+}
+
+func (server *Server) queryIterator(ctx context.Context, path string, query ast.Query, opts *QueryOptions) (*iterator, error) {
+	url, err := queryURL(path, query, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := server.queryResponse(withStreaming(ctx), url, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &iterator{body: resp.Body, dec: json.NewDecoder(resp.Body)}
+	tok, err := it.dec.Token()
+	if err != nil {
+		it.Close()
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		it.Close()
+		return nil, fmt.Errorf("expected a JSON array from %s, got %v", path, tok)
+	}
+	return it, nil
+}
+
+func (it *iterator) next(v interface{}) bool {
+	if it.done {
+		return false
+	}
+	if !it.dec.More() {
+		// Consume the closing bracket so a truncated body is reported
+		if _, err := it.dec.Token(); err != nil {
+			it.err = err
+		}
+		it.Close()
+		return false
+	}
+	if err := it.dec.Decode(v); err != nil {
+		it.err = err
+		it.Close()
+		return false
+	}
+	return true
+}
+
+// Err returns the first error encountered while decoding, if any
+func (it *iterator) Err() error {
+	return it.err
+}
+
+// Close releases the underlying response body. It is safe to call Close more
+// than once, and iteration stops once the iterator is closed.
+func (it *iterator) Close() error {
+	if it.done {
+		return nil
+	}
+	it.done = true
+	return it.body.Close()
+}
+
+/*
+ResourceIterator - Streams the results of a resources query.
+
+	it, err := server.QueryResourcesIterator(query, nil)
+	if err != nil {
+		...
+	}
+	defer it.Close()
+	for it.Next() {
+		resource := it.Value()
+		...
+	}
+	if err := it.Err(); err != nil {
+		...
+	}
+*/
+type ResourceIterator struct {
+	*iterator
+	value CatalogResource
+}
+
+// Next decodes the next resource, returning false at the end of the results or on error
+func (it *ResourceIterator) Next() bool {
+	var value CatalogResource
+	ok := it.next(&value)
+	it.value = value
+	return ok
+}
+
+// Value returns the resource decoded by the last call to Next
+func (it *ResourceIterator) Value() CatalogResource {
+	return it.value
+}
+
+/*
+QueryResourcesIterator - Query the PuppetDB instance resources end-point,
+streaming the results.
+*/
+func (server *Server) QueryResourcesIterator(query ast.Query, opts *QueryOptions) (*ResourceIterator, error) {
+	return server.QueryResourcesIteratorContext(context.Background(), query, opts)
+}
+
+/*
+QueryResourcesIteratorContext - QueryResourcesIterator with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryResourcesIteratorContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*ResourceIterator, error) {
+	it, err := server.queryIterator(ctx, "pdb/query/v4/resources", query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ResourceIterator{iterator: it}, nil
+}
+
+/*
+EventIterator - Streams the results of an events query.
+*/
+type EventIterator struct {
+	*iterator
+	value Event
+}
+
+// Next decodes the next event, returning false at the end of the results or on error
+func (it *EventIterator) Next() bool {
+	var value Event
+	ok := it.next(&value)
+	it.value = value
+	return ok
+}
+
+// Value returns the event decoded by the last call to Next
+func (it *EventIterator) Value() Event {
+	return it.value
+}
+
+/*
+QueryEventsIterator - Query the PuppetDB instance events end-point, streaming
+the results.
+*/
+func (server *Server) QueryEventsIterator(query ast.Query, opts *QueryOptions) (*EventIterator, error) {
+	return server.QueryEventsIteratorContext(context.Background(), query, opts)
+}
+
+/*
+QueryEventsIteratorContext - QueryEventsIterator with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEventsIteratorContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*EventIterator, error) {
+	it, err := server.queryIterator(ctx, "pdb/query/v4/events", query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &EventIterator{iterator: it}, nil
+}
+
+/*
+ReportIterator - Streams the results of a reports query.
+*/
+type ReportIterator struct {
+	*iterator
+	value Report
+}
+
+// Next decodes the next report, returning false at the end of the results or on error
+func (it *ReportIterator) Next() bool {
+	var value Report
+	ok := it.next(&value)
+	it.value = value
+	return ok
+}
+
+// Value returns the report decoded by the last call to Next
+func (it *ReportIterator) Value() Report {
+	return it.value
+}
+
+/*
+QueryReportsIterator - Query the PuppetDB instance reports end-point,
+streaming the results.
+*/
+func (server *Server) QueryReportsIterator(query ast.Query, opts *QueryOptions) (*ReportIterator, error) {
+	return server.QueryReportsIteratorContext(context.Background(), query, opts)
+}
+
+/*
+QueryReportsIteratorContext - QueryReportsIterator with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryReportsIteratorContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*ReportIterator, error) {
+	it, err := server.queryIterator(ctx, "pdb/query/v4/reports", query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ReportIterator{iterator: it}, nil
+}
+
+/*
+FactIterator - Streams the results of a facts query.
+*/
+type FactIterator struct {
+	*iterator
+	value Fact
+}
+
+// Next decodes the next fact, returning false at the end of the results or on error
+func (it *FactIterator) Next() bool {
+	var value Fact
+	ok := it.next(&value)
+	it.value = value
+	return ok
+}
+
+// Value returns the fact decoded by the last call to Next
+func (it *FactIterator) Value() Fact {
+	return it.value
+}
+
+/*
+QueryFactsIterator - Query the PuppetDB instance facts end-point, streaming
+the results.
+*/
+func (server *Server) QueryFactsIterator(query ast.Query, opts *QueryOptions) (*FactIterator, error) {
+	return server.QueryFactsIteratorContext(context.Background(), query, opts)
+}
+
+/*
+QueryFactsIteratorContext - QueryFactsIterator with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactsIteratorContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*FactIterator, error) {
+	it, err := server.queryIterator(ctx, "pdb/query/v4/facts", query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &FactIterator{iterator: it}, nil
+}
+
+/*
+NodeIterator - Streams the results of a nodes query.
+*/
+type NodeIterator struct {
+	*iterator
+	value Node
+}
+
+// Next decodes the next node, returning false at the end of the results or on error
+func (it *NodeIterator) Next() bool {
+	var value Node
+	ok := it.next(&value)
+	it.value = value
+	return ok
+}
+
+// Value returns the node decoded by the last call to Next
+func (it *NodeIterator) Value() Node {
+	return it.value
+}
+
+/*
+QueryNodesIterator - Query the PuppetDB instance nodes end-point, streaming
+the results.
+*/
+func (server *Server) QueryNodesIterator(query ast.Query, opts *QueryOptions) (*NodeIterator, error) {
+	return server.QueryNodesIteratorContext(context.Background(), query, opts)
+}
+
+/*
+QueryNodesIteratorContext - QueryNodesIterator with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryNodesIteratorContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*NodeIterator, error) {
+	it, err := server.queryIterator(ctx, "pdb/query/v4/nodes", query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeIterator{iterator: it}, nil
+}
@@ -0,0 +1,150 @@
+package puppetdb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResourceIterator(t *testing.T) {
+	body := `[{"type": "File", "title": "/etc/hosts"}, {"type": "Class", "title": "Main"}, {"type": "Service", "title": "sshd"}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pdb/query/v4/resources" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		// Leave the array unterminated for the truncation check below
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	server := NewServer(ts.URL + "/")
+	it, err := server.QueryResourcesIterator(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for it.Next() {
+		titles = append(titles, it.Value().Title)
+	}
+	if len(titles) != 3 || titles[0] != "/etc/hosts" || titles[2] != "sshd" {
+		t.Errorf("unexpected titles %v", titles)
+	}
+	if it.Err() == nil {
+		t.Error("expected an error for a truncated response")
+	}
+
+	body += "]"
+	it, err = server.QueryResourcesIterator(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !it.Next() || it.Value().Type != "File" {
+		t.Fatal("expected a first resource")
+	}
+	if err := it.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if it.Next() {
+		t.Error("expected Next to stop after Close")
+	}
+	if it.Err() != nil {
+		t.Errorf("unexpected error %v", it.Err())
+	}
+}
+
+func TestIteratorSlowStream(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"type": "File", "title": "/etc/hosts"},`))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte(`{"type": "Service", "title": "sshd"}]`))
+	}))
+	defer ts.Close()
+
+	server := NewServer(ts.URL + "/")
+	server.SetHTTPTimeout(50 * time.Millisecond)
+
+	// The stream outlasts the timeout, which only applies until it begins
+	it, err := server.QueryResourcesIterator(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	if !it.Next() {
+		t.Fatalf("expected a first resource, error %v", it.Err())
+	}
+	time.AfterFunc(150*time.Millisecond, func() { close(release) })
+	count := 1
+	for it.Next() {
+		count++
+	}
+	if err := it.Err(); err != nil || count != 2 {
+		t.Errorf("read %d resources, error %v", count, err)
+	}
+}
+
+func TestIteratorNoResponse(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	server := NewServer(ts.URL + "/")
+	server.SetHTTPTimeout(50 * time.Millisecond)
+
+	_, err := server.QueryResourcesIterator(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "within") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the underlying error to be kept, got %v", err)
+	}
+}
+
+func TestIteratorErrors(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch {
+		case r.URL.Query().Get("limit") == "1":
+			http.Error(w, "not found", http.StatusNotFound)
+		case attempts == 1:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`[{"type": "File", "title": "/etc/hosts"}]`))
+		}
+	}))
+	defer ts.Close()
+
+	server := NewServer(ts.URL + "/")
+	server.SetHTTPTimeout(50 * time.Millisecond)
+	server.SetRetryPolicy(&RetryPolicy{
+		MaxAttempts:     2,
+		MinBackoff:      100 * time.Millisecond,
+		RetryableStatus: []int{http.StatusServiceUnavailable},
+	})
+
+	// Backing off between attempts doesn't count against the timeout
+	it, err := server.QueryResourcesIterator(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	if !it.Next() || attempts != 2 {
+		t.Errorf("expected a resource after %d attempts, error %v", attempts, it.Err())
+	}
+
+	if _, err := server.QueryResourcesIterator(nil, &QueryOptions{Limit: 1}); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
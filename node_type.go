@@ -1,16 +1,62 @@
 package puppetdb
 
+import "time"
+
 /*
 Node struct -
 Data structure representative of the return wire format from nodes query
 end-points.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/query/v3/nodes.html#get-v3nodes
+Fields PuppetDB may return as null, such as the timestamps of data a node
+has never submitted, are pointers and nil when null.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/nodes.html#response-format
 */
 type Node struct {
-	Name             string `json:"name"`
-	Deactivated      string `json:"deactivated"`
-	CatalogTimestamp string `json:"catalog_timestamp"`
-	FactsTimestamp   string `json:"facts_timestamp"`
-	ReportTimestamp  string `json:"report_timestamp"`
+	Certname    string     `json:"certname"`
+	Deactivated *time.Time `json:"deactivated"`
+	Expired     *time.Time `json:"expired"`
+
+	CatalogTimestamp   *time.Time `json:"catalog_timestamp"`
+	CatalogEnvironment *string    `json:"catalog_environment"`
+	FactsTimestamp     *time.Time `json:"facts_timestamp"`
+	FactsEnvironment   *string    `json:"facts_environment"`
+	ReportTimestamp    *time.Time `json:"report_timestamp"`
+	ReportEnvironment  *string    `json:"report_environment"`
+
+	LatestReportStatus           *string `json:"latest_report_status"`
+	LatestReportHash             *string `json:"latest_report_hash"`
+	LatestReportJobID            *string `json:"latest_report_job_id"`
+	LatestReportNoop             *bool   `json:"latest_report_noop"`
+	LatestReportNoopPending      *bool   `json:"latest_report_noop_pending"`
+	LatestReportCorrectiveChange *bool   `json:"latest_report_corrective_change"`
+	CachedCatalogStatus          *string `json:"cached_catalog_status"`
+}
+
+/*
+IsActive - Whether the node is neither deactivated nor expired.
+
+Inactive nodes are only returned when querying with node_state.
+*/
+func (n Node) IsActive() bool {
+	return n.Deactivated == nil && n.Expired == nil
+}
+
+/*
+IsStale - Whether the node has not reported within threshold of now,
+including nodes that have never reported.
+*/
+func (n Node) IsStale(threshold time.Duration) bool {
+	return n.ReportTimestamp == nil || time.Since(*n.ReportTimestamp) > threshold
+}
+
+/*
+LastRunStatus - Status of the node's latest report: changed, unchanged or
+failed, or unreported when it has none.
+*/
+func (n Node) LastRunStatus() string {
+	if n.LatestReportStatus == nil {
+		return "unreported"
+	}
+	return *n.LatestReportStatus
 }
@@ -0,0 +1,35 @@
+package puppetdb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNode(t *testing.T) {
+	var nodes []Node
+	err := json.Unmarshal([]byte(`[
+		{"certname": "active.example.com", "deactivated": null, "expired": null,
+		 "report_timestamp": "`+time.Now().Add(-10*time.Minute).UTC().Format(time.RFC3339Nano)+`",
+		 "latest_report_status": "changed", "latest_report_noop": false, "report_environment": "production"},
+		{"certname": "gone.example.com", "deactivated": "2020-09-13T12:26:40.000Z", "expired": null,
+		 "report_timestamp": null, "latest_report_status": null, "latest_report_noop": null}
+	]`), &nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, gone := nodes[0], nodes[1]
+	if !active.IsActive() || active.IsStale(time.Hour) || !active.IsStale(time.Minute) {
+		t.Errorf("unexpected state for %+v", active)
+	}
+	if active.LastRunStatus() != "changed" || active.LatestReportNoop == nil || *active.LatestReportNoop {
+		t.Errorf("unexpected latest report for %+v", active)
+	}
+	if gone.IsActive() || !gone.IsStale(time.Hour) || gone.LastRunStatus() != "unreported" {
+		t.Errorf("unexpected state for %+v", gone)
+	}
+	if gone.Deactivated.Year() != 2020 || gone.LatestReportNoop != nil {
+		t.Errorf("unexpected fields for %+v", gone)
+	}
+}
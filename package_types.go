@@ -0,0 +1,17 @@
+package puppetdb
+
+/*
+Package - A package installed on nodes, as returned by the packages and
+package-inventory query end-points.
+
+Certname is only set by package-inventory, which lists each package once per
+node, while packages lists each distinct package once.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/packages.html#response-format
+*/
+type Package struct {
+	Certname    string `json:"certname,omitempty"`
+	PackageName string `json:"package_name"`
+	Version     string `json:"version"`
+	Provider    string `json:"provider"`
+}
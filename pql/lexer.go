@@ -0,0 +1,139 @@
+package pql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// Operators, longest first so "<=" wins over "<"
+var punctuation = []string{"!=", "!~", "~>", "<=", ">=", "=", "~", "<", ">", "!", "[", "]", "{", "}", "(", ")", ","}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for pos < len(input) {
+		c := rune(input[pos])
+		switch {
+		case unicode.IsSpace(c):
+			pos++
+		case c == '"' || c == '\'':
+			text, end, err := lexString(input, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokString, text, pos})
+			pos = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := pos + 1
+			for end < len(input) && (isDigit(input[end]) || input[end] == '.' || input[end] == 'e' || input[end] == 'E') {
+				end++
+			}
+			if input[pos:end] == "-" {
+				return nil, &SyntaxError{Pos: pos, Msg: "unexpected \"-\""}
+			}
+			tokens = append(tokens, token{tokNumber, input[pos:end], pos})
+			pos = end
+		case isIdentStart(c):
+			end := pos
+			for end < len(input) {
+				if isIdentChar(rune(input[end])) {
+					end++
+					continue
+				}
+				// Quoted path segments, as in facts."my.fact"
+				if input[end] == '"' && end > pos && input[end-1] == '.' {
+					_, next, err := lexString(input, end)
+					if err != nil {
+						return nil, err
+					}
+					end = next
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, token{tokIdent, input[pos:end], pos})
+			pos = end
+		default:
+			matched := false
+			for _, p := range punctuation {
+				if strings.HasPrefix(input[pos:], p) {
+					tokens = append(tokens, token{tokPunct, p, pos})
+					pos += len(p)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, &SyntaxError{Pos: pos, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+		}
+	}
+	return append(tokens, token{tokEOF, "", pos}), nil
+}
+
+func lexString(input string, start int) (string, int, error) {
+	quote := input[start]
+	var sb strings.Builder
+	for pos := start + 1; pos < len(input); pos++ {
+		c := input[pos]
+		switch {
+		case c == quote:
+			return sb.String(), pos + 1, nil
+		case c == '\\' && pos+1 < len(input):
+			pos++
+			switch input[pos] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				sb.WriteByte(input[pos])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, &SyntaxError{Pos: start, Msg: "unterminated string"}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c rune) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '.' || c == '?' || c == '-'
+}
@@ -0,0 +1,562 @@
+/*
+Package pql - Client-side parser for the Puppet Query Language.
+
+Parse turns a PQL string into the equivalent typed AST query from the ast
+package, which catches syntax errors before a query is sent and allows PQL
+to be used anywhere an ast.Query is accepted.
+
+	q, err := pql.Parse(`nodes[certname] { facts { name = "os" } }`)
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/pql.html
+*/
+package pql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+Entities - The entity names a PQL query may select from.
+*/
+var Entities = map[string]bool{
+	"aggregate_event_counts": true,
+	"catalogs":               true,
+	"edges":                  true,
+	"environments":           true,
+	"event_counts":           true,
+	"events":                 true,
+	"fact_contents":          true,
+	"fact_names":             true,
+	"fact_paths":             true,
+	"facts":                  true,
+	"factsets":               true,
+	"inventory":              true,
+	"nodes":                  true,
+	"package_inventory":      true,
+	"packages":               true,
+	"producers":              true,
+	"reports":                true,
+	"resources":              true,
+}
+
+/*
+SyntaxError - Returned by Parse when a query is not valid PQL.
+
+Pos is the byte offset into the query where the problem was found.
+*/
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("pql: %s at position %d", e.Msg, e.Pos)
+}
+
+/*
+Parse - Parse a PQL query into an AST query.
+
+The result is always an ast.From, which may be sent to the root query
+end-point or nested within other queries.
+*/
+func Parse(query string) (ast.From, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return ast.From{}, err
+	}
+	p := &parser{tokens: tokens}
+	from, err := p.parseEntityQuery()
+	if err != nil {
+		return ast.From{}, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return ast.From{}, p.unexpected(tok)
+	}
+	return from, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isPunct(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokPunct && tok.text == text
+}
+
+func (p *parser) isKeyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokIdent && tok.text == word
+}
+
+func (p *parser) expectPunct(text string) error {
+	if !p.isPunct(text) {
+		return p.errorf(p.peek(), "expected %q but found %v", text, p.peek())
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) expectKeyword(word string) error {
+	if !p.isKeyword(word) {
+		return p.errorf(p.peek(), "expected %q but found %v", word, p.peek())
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) unexpected(tok token) error {
+	return p.errorf(tok, "unexpected %v", tok)
+}
+
+// entity [projection] { [expression] [modifiers] }
+func (p *parser) parseEntityQuery() (ast.From, error) {
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return ast.From{}, p.errorf(tok, "expected an entity but found %v", tok)
+	}
+	if !Entities[tok.text] {
+		return ast.From{}, p.errorf(tok, "unknown entity %q", tok.text)
+	}
+	from := ast.From{Entity: tok.text}
+
+	var extract *ast.Extract
+	if p.isPunct("[") {
+		columns, err := p.parseProjection()
+		if err != nil {
+			return ast.From{}, err
+		}
+		extract = &columns
+	}
+
+	if err := p.expectPunct("{"); err != nil {
+		return ast.From{}, err
+	}
+	var query ast.Query
+	if !p.isPunct("}") && !p.atModifier() {
+		var err error
+		if query, err = p.parseExpression(); err != nil {
+			return ast.From{}, err
+		}
+	}
+	groupBy, err := p.parseModifiers(&from)
+	if err != nil {
+		return ast.From{}, err
+	}
+	if err := p.expectPunct("}"); err != nil {
+		return ast.From{}, err
+	}
+
+	if extract == nil {
+		if len(groupBy) > 0 {
+			return ast.From{}, p.errorf(tok, "group by requires a projection")
+		}
+		from.Query = query
+		return from, nil
+	}
+	extract.Query = query
+	extract.GroupBy = groupBy
+	from.Query = *extract
+	return from, nil
+}
+
+// [field, function(args), ...]
+func (p *parser) parseProjection() (ast.Extract, error) {
+	var extract ast.Extract
+	p.next()
+	for {
+		tok := p.next()
+		if tok.kind != tokIdent {
+			return extract, p.errorf(tok, "expected a field but found %v", tok)
+		}
+		if p.isPunct("(") {
+			fn, err := p.parseFunction(tok.text)
+			if err != nil {
+				return extract, err
+			}
+			extract.Functions = append(extract.Functions, fn)
+		} else {
+			extract.Fields = append(extract.Fields, tok.text)
+		}
+		if p.isPunct("]") {
+			p.next()
+			break
+		}
+		if err := p.expectPunct(","); err != nil {
+			return extract, err
+		}
+	}
+	return extract, nil
+}
+
+func (p *parser) parseFunction(name string) (ast.Function, error) {
+	fn := ast.Function{Name: name}
+	p.next()
+	for !p.isPunct(")") {
+		tok := p.next()
+		if tok.kind != tokIdent && tok.kind != tokString {
+			return fn, p.errorf(tok, "expected a function argument but found %v", tok)
+		}
+		fn.Args = append(fn.Args, tok.text)
+		if !p.isPunct(")") {
+			if err := p.expectPunct(","); err != nil {
+				return fn, err
+			}
+		}
+	}
+	p.next()
+	return fn, nil
+}
+
+func (p *parser) atModifier() bool {
+	switch {
+	case p.isKeyword("limit"), p.isKeyword("offset"):
+		return p.peekAt(1).kind == tokNumber
+	case p.isKeyword("order"), p.isKeyword("group"):
+		next := p.peekAt(1)
+		return next.kind == tokIdent && next.text == "by"
+	}
+	return false
+}
+
+// group by ..., order by ... [asc|desc], limit N, offset N
+func (p *parser) parseModifiers(from *ast.From) (ast.GroupBy, error) {
+	var groupBy ast.GroupBy
+	for p.atModifier() {
+		switch keyword := p.next().text; keyword {
+		case "limit", "offset":
+			tok := p.next()
+			n, err := strconv.Atoi(tok.text)
+			if err != nil || n < 0 {
+				return nil, p.errorf(tok, "invalid %s %v", keyword, tok)
+			}
+			if keyword == "limit" {
+				from.Limit = n
+			} else {
+				from.Offset = n
+			}
+		case "group":
+			p.next()
+			fields, err := p.parseFieldList()
+			if err != nil {
+				return nil, err
+			}
+			groupBy = append(groupBy, fields...)
+		case "order":
+			p.next()
+			for {
+				field, err := p.parseField()
+				if err != nil {
+					return nil, err
+				}
+				order := ast.OrderBy{Field: field}
+				if p.isKeyword("desc") {
+					order.Descending = true
+					p.next()
+				} else if p.isKeyword("asc") {
+					p.next()
+				}
+				from.OrderBy = append(from.OrderBy, order)
+				if !p.isPunct(",") {
+					break
+				}
+				p.next()
+			}
+		}
+	}
+	return groupBy, nil
+}
+
+// field, field, ...
+func (p *parser) parseFieldList() ([]string, error) {
+	var fields []string
+	for {
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+		if !p.isPunct(",") {
+			return fields, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseField() (string, error) {
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return "", p.errorf(tok, "expected a field but found %v", tok)
+	}
+	return tok.text, nil
+}
+
+func (p *parser) parseExpression() (ast.Query, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isKeyword("or") {
+		return left, nil
+	}
+	or := ast.Or{left}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, right)
+	}
+	return or, nil
+}
+
+func (p *parser) parseAnd() (ast.Query, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isKeyword("and") {
+		return left, nil
+	}
+	and := ast.And{left}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, right)
+	}
+	return and, nil
+}
+
+func (p *parser) parseNot() (ast.Query, error) {
+	if p.isPunct("!") {
+		p.next()
+		query, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return ast.Not{Query: query}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (ast.Query, error) {
+	tok := p.peek()
+	switch {
+	case p.isPunct("("):
+		p.next()
+		query, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return query, nil
+	case p.isPunct("["):
+		p.next()
+		fields, err := p.parseFieldList()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return nil, err
+		}
+		return p.parseIn(fields)
+	case tok.kind == tokIdent && Entities[tok.text] && p.peekAt(1).kind == tokPunct && p.peekAt(1).text == "{":
+		p.next()
+		p.next()
+		subquery := ast.Subquery{Entity: tok.text}
+		if !p.isPunct("}") {
+			query, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			subquery.Query = query
+		}
+		if err := p.expectPunct("}"); err != nil {
+			return nil, err
+		}
+		return subquery, nil
+	case tok.kind == tokIdent:
+		p.next()
+		return p.parseCondition(tok.text)
+	}
+	return nil, p.unexpected(tok)
+}
+
+func (p *parser) parseCondition(field string) (ast.Query, error) {
+	tok := p.next()
+	if tok.kind == tokIdent {
+		switch tok.text {
+		case "in":
+			p.pos--
+			return p.parseIn([]string{field})
+		case "is":
+			isNull := true
+			if p.isKeyword("not") {
+				p.next()
+				isNull = false
+			}
+			if err := p.expectKeyword("null"); err != nil {
+				return nil, err
+			}
+			return ast.Null{Field: field, IsNull: isNull}, nil
+		}
+	}
+	if tok.kind != tokPunct {
+		return nil, p.errorf(tok, "expected an operator but found %v", tok)
+	}
+
+	// Array fields such as fact paths are compared against an array
+	if p.isPunct("[") && (tok.text == "=" || tok.text == "~>") {
+		array, err := p.parseArray()
+		if err != nil {
+			return nil, err
+		}
+		if tok.text == "=" {
+			return ast.Equals{Field: field, Value: []interface{}(array)}, nil
+		}
+		patterns := make([]string, len(array))
+		for i, element := range array {
+			pattern, ok := element.(string)
+			if !ok {
+				return nil, p.errorf(tok, "regular expressions for ~> must be strings")
+			}
+			patterns[i] = pattern
+		}
+		return ast.RegexArray{Field: field, Patterns: patterns}, nil
+	}
+
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.text {
+	case "=":
+		return ast.Equals{Field: field, Value: value}, nil
+	case "!=":
+		return ast.Not{Query: ast.Equals{Field: field, Value: value}}, nil
+	case "<":
+		return ast.LessThan{Field: field, Value: value}, nil
+	case "<=":
+		return ast.LessThanOrEqual{Field: field, Value: value}, nil
+	case ">":
+		return ast.GreaterThan{Field: field, Value: value}, nil
+	case ">=":
+		return ast.GreaterThanOrEqual{Field: field, Value: value}, nil
+	case "~", "!~":
+		pattern, ok := value.(string)
+		if !ok {
+			return nil, p.errorf(tok, "regular expression must be a string")
+		}
+		if tok.text == "!~" {
+			return ast.Not{Query: ast.Regex{Field: field, Pattern: pattern}}, nil
+		}
+		return ast.Regex{Field: field, Pattern: pattern}, nil
+	}
+	return nil, p.errorf(tok, "unknown operator %v", tok)
+}
+
+// in [literal, ...] | in entity[fields] { ... }
+func (p *parser) parseIn(fields []string) (ast.Query, error) {
+	if err := p.expectKeyword("in"); err != nil {
+		return nil, err
+	}
+	if p.isPunct("[") {
+		array, err := p.parseArray()
+		if err != nil {
+			return nil, err
+		}
+		return ast.In{Fields: fields, Source: array}, nil
+	}
+	tok := p.peek()
+	source, err := p.parseEntityQuery()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := source.Query.(ast.Extract); !ok {
+		return nil, p.errorf(tok, "subquery for in must have a projection")
+	}
+	return ast.In{Fields: fields, Source: source}, nil
+}
+
+// [literal, ...]
+func (p *parser) parseArray() (ast.Array, error) {
+	if err := p.expectPunct("["); err != nil {
+		return nil, err
+	}
+	array := ast.Array{}
+	for !p.isPunct("]") {
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+		if !p.isPunct("]") {
+			if err := p.expectPunct(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.next()
+	return array, nil
+}
+
+func (p *parser) parseLiteral() (interface{}, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokString:
+		return tok.text, nil
+	case tokNumber:
+		if !strings.ContainsAny(tok.text, ".eE") {
+			if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+				return n, nil
+			}
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %v", tok)
+		}
+		return f, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return nil, p.errorf(tok, "expected a value but found %v", tok)
+}
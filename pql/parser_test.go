@@ -0,0 +1,83 @@
+package pql
+
+import (
+	"testing"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		pql  string
+		want string
+	}{
+		{`nodes {}`, `["from","nodes"]`},
+		{
+			`nodes[certname] { facts { name = "os" } }`,
+			`["from","nodes",["extract",["certname"],["subquery","facts",["=","name","os"]]]]`,
+		},
+		{
+			`facts { name = 'kernel' and !(value = "Linux" or value ~ "^Win") }`,
+			`["from","facts",["and",["=","name","kernel"],["not",["or",["=","value","Linux"],["~","value","^Win"]]]]]`,
+		},
+		{
+			`nodes { report_timestamp >= "2020-01-01" and deactivated is null and expired is not null }`,
+			`["from","nodes",["and",[">=","report_timestamp","2020-01-01"],["null?","deactivated",true],["null?","expired",false]]]`,
+		},
+		{
+			`inventory[certname] { facts.processors.count > 4 and facts.os.name != "Darwin" }`,
+			`["from","inventory",["extract",["certname"],["and",[">","facts.processors.count",4],["not",["=","facts.os.name","Darwin"]]]]]`,
+		},
+		{
+			`reports[status, count()] { certname in ["a", "b"] group by status }`,
+			`["from","reports",["extract",[["function","count"],"status"],["in","certname",["array",["a","b"]]],["group_by","status"]]]`,
+		},
+		{
+			`nodes { certname in facts[certname] { name = "osfamily" and value = "RedHat" } order by certname desc limit 10 offset 20 }`,
+			`["from","nodes",["in","certname",["from","facts",["extract",["certname"],["and",["=","name","osfamily"],["=","value","RedHat"]]]]],["order_by",[["certname","desc"]]],["limit",10],["offset",20]]`,
+		},
+		{
+			`fact_contents[certname, value] { path ~> ["networking", "interfaces", ".*", "ip"] or path = ["processors", "models", 0] }`,
+			`["from","fact_contents",["extract",["certname","value"],["or",["~>","path",["networking","interfaces",".*","ip"]],["=","path",["processors","models",0]]]]]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pql, func(t *testing.T) {
+			q, err := Parse(tt.pql)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ast.Marshal(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`nodes`,
+		`widgets {}`,
+		`nodes { certname = }`,
+		`nodes { certname = "foo" `,
+		`nodes { certname = "foo }`,
+		`nodes[] {}`,
+		`nodes { certname in facts { name = "x" } }`,
+		`nodes { group by certname }`,
+		`nodes {} extra`,
+		`fact_contents { path ~> "os" }`,
+		`fact_contents { path ~> ["os", 0] }`,
+	}
+	for _, pql := range tests {
+		if _, err := Parse(pql); err == nil {
+			t.Errorf("Parse(%q) expected an error", pql)
+		} else if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("Parse(%q) returned %T, want *SyntaxError", pql, err)
+		}
+	}
+}
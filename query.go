@@ -1,12 +1,17 @@
 package puppetdb
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -16,45 +21,126 @@ var apiVersion = ""
 Query - Generic query function.
 */
 func (server *Server) Query(url string) ([]byte, error) {
-	baseURL := server.BaseURL
+	return server.QueryContext(context.Background(), url)
+}
 
-	fullURL := strings.Join([]string{baseURL, url}, "")
+/*
+QueryContext - Query with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryContext(ctx context.Context, url string) ([]byte, error) {
+	return server.request(ctx, "GET", url, nil)
+}
+
+/*
+queryResponse - Send a query built by queryURL, as a GET or, when forced by
+opts or the encoded query is longer than the server's threshold, as a POST
+with the parameters in a JSON body.
+*/
+func (server *Server) queryResponse(ctx context.Context, url string, opts *QueryOptions) (*http.Response, error) {
+	if !opts.forcePOST() && !server.exceedsPOSTThreshold(url) {
+		return server.QueryResponseContext(ctx, "GET", url, nil)
+	}
+
+	path, requestJSON, err := queryBody(url)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("POST %s body=%s\n", path, requestJSON)
+	return server.QueryResponseContext(ctx, "POST", path, bytes.NewReader(requestJSON))
+}
 
-	req, err := http.NewRequest("GET", fullURL, server.Body)
+// query is queryResponse returning the whole response body
+func (server *Server) query(ctx context.Context, url string, opts *QueryOptions) ([]byte, error) {
+	resp, err := server.queryResponse(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	req.Header.Set("Content-Type", "application/json")
-	// Set any additional headers such as authentication, proxy, etc
-	for key, value := range server.Headers {
-		req.Header.Set(key, value)
+	return ioutil.ReadAll(resp.Body)
+}
+
+// queryInto queries path with an AST query and paging options, decoding the response into v
+func (server *Server) queryInto(ctx context.Context, path string, query ast.Query, opts *QueryOptions, v interface{}) error {
+	url, err := queryURL(path, query, opts, nil)
+	if err != nil {
+		return err
 	}
 
-	client := &http.Client{Transport: server.HTTPTransport, Timeout: server.HTTPTimeout}
-	resp, err := client.Do(req)
+	body, err := server.query(ctx, url, opts)
+	if err != nil {
+		return err
+	}
+
+	return decodeResponse(url, body, v)
+}
+
+func (server *Server) request(ctx context.Context, method string, url string, requestBody io.Reader) ([]byte, error) {
+	resp, err := server.QueryResponseContext(ctx, method, url, requestBody)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	return ioutil.ReadAll(resp.Body)
 }
 
+/*
+QueryResponse - Perform a query request and return the raw HTTP response.
+
+This is the lowest level query function, useful when response headers such
+as X-Records are needed. The caller is responsible for closing the body. A
+response with a non-2xx status is returned as an *APIError instead.
+*/
+func (server *Server) QueryResponse(method string, url string, requestBody io.Reader) (*http.Response, error) {
+	return server.QueryResponseContext(context.Background(), method, url, requestBody)
+}
+
+/*
+QueryResponseContext - QueryResponse with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryResponseContext(ctx context.Context, method string, url string, requestBody io.Reader) (*http.Response, error) {
+	baseURL := server.BaseURL
+
+	fullURL := strings.Join([]string{baseURL, url}, "")
+
+	var body []byte
+	if requestBody != nil {
+		var err error
+		if body, err = ioutil.ReadAll(requestBody); err != nil {
+			return nil, err
+		}
+	}
+
+	// Queries never change anything, so are always safe to retry
+	return server.do(ctx, method, fullURL, body, "application/json", true)
+}
+
 /*
 QueryVersion queries the PuppetDB instance version end-point.
 
 More details here: https://puppet.com/docs/puppetdb/5.2/api/meta/v1/version.html
 */
 func (server *Server) QueryVersion() (*Version, error) {
-	body, err := server.Query("pdb/meta/v1/version")
+	return server.QueryVersionContext(context.Background())
+}
+
+/*
+QueryVersionContext - QueryVersion with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryVersionContext(ctx context.Context) (*Version, error) {
+	url := "pdb/meta/v1/version"
+	body, err := server.QueryContext(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
 	var version Version
-	json.Unmarshal(body, &version)
+	if err := decodeResponse(url, body, &version); err != nil {
+		return nil, err
+	}
 
-	return &version, err
+	return &version, nil
 }
 
 /*
@@ -63,15 +149,25 @@ QueryServerTime - query the PuppetDB instance server-time end-point.
 More details here: https://puppet.com/docs/puppetdb/latest/api/meta/v1/server-time.html
 */
 func (server *Server) QueryServerTime() (*ServerTime, error) {
-	body, err := server.Query("pdb/meta/v1/server-time")
+	return server.QueryServerTimeContext(context.Background())
+}
+
+/*
+QueryServerTimeContext - QueryServerTime with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryServerTimeContext(ctx context.Context) (*ServerTime, error) {
+	url := "pdb/meta/v1/server-time"
+	body, err := server.QueryContext(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
 	var serverTime ServerTime
-	json.Unmarshal(body, &serverTime)
+	if err := decodeResponse(url, body, &serverTime); err != nil {
+		return nil, err
+	}
 
-	return &serverTime, err
+	return &serverTime, nil
 }
 
 /*
@@ -79,55 +175,150 @@ QueryFactNames - Query the PuppetDB instance fact-names end-point.
 
 More details here: http://docs.puppetlabs.com/puppetdb/latest/api/query/v3/fact-names.html
 */
-func (server *Server) QueryFactNames() ([]string, error) {
-	body, err := server.Query("pdb/query/v4/fact-names")
+func (server *Server) QueryFactNames(opts *QueryOptions) ([]string, error) {
+	return server.QueryFactNamesContext(context.Background(), opts)
+}
+
+/*
+QueryFactNamesContext - QueryFactNames with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactNamesContext(ctx context.Context, opts *QueryOptions) ([]string, error) {
+	url, err := queryURL("pdb/query/v4/fact-names", nil, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := server.query(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var factNames []string
-	json.Unmarshal(body, &factNames)
+	if err := decodeResponse(url, body, &factNames); err != nil {
+		return nil, err
+	}
 
-	return factNames, err
+	return factNames, nil
 }
 
 /*
 QueryCatalogs - the PuppetDB instance catalogs end-point.
 
-More details here: https://puppet.com/docs/puppetdb/5.2/api/query/v4/catalogs.html
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/catalogs.html
 */
-func (server *Server) QueryCatalogs(certname string) (*CatalogWireFormat, error) {
-	url := fmt.Sprintf("pdb/query/v4/catalogs/%v", certname)
-	body, err := server.Query(url)
+func (server *Server) QueryCatalogs(certname string) (*Catalog, error) {
+	return server.QueryCatalogsContext(context.Background(), certname)
+}
+
+/*
+QueryCatalogsContext - QueryCatalogs with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryCatalogsContext(ctx context.Context, certname string) (*Catalog, error) {
+	url := fmt.Sprintf("pdb/query/v4/catalogs/%v", url.PathEscape(certname))
+	body, err := server.QueryContext(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
-	var catalog CatalogWireFormat
-	json.Unmarshal(body, &catalog)
+	var catalog Catalog
+	if err := decodeResponse(url, body, &catalog); err != nil {
+		return nil, err
+	}
 
-	return &catalog, err
+	return &catalog, nil
 }
 
 /*
-BuildQueryInventory will take in the fact and the query
+queryURL - Build the URL for a query end-point, adding the serialized AST query,
+paging options and any additional parameters to the query string.
+
+A nil query is omitted, returning everything the end-point has to offer.
 */
-func (server *Server) BuildQueryInventory(queryElements ...string) (*[]Inventory, error) {
-	queryString := ""
-	for i, query := range queryElements {
-		if i == 0 {
-			queryString += "?query=["
+func queryURL(path string, query ast.Query, opts *QueryOptions, params url.Values) (string, error) {
+	values, err := opts.values()
+	if err != nil {
+		return "", err
+	}
+	for key, value := range params {
+		values[key] = value
+	}
+	if query != nil {
+		queryString, err := ast.Marshal(query)
+		if err != nil {
+			return "", err
+		}
+		values.Set("query", queryString)
+	}
+	if len(values) == 0 {
+		return path, nil
+	}
+	return path + "?" + values.Encode(), nil
+}
+
+func (server *Server) exceedsPOSTThreshold(url string) bool {
+	threshold := server.QueryPOSTThreshold
+	if threshold == 0 {
+		threshold = DefaultQueryPOSTThreshold
+	}
+	i := strings.Index(url, "?")
+	return threshold > 0 && i >= 0 && len(url)-i-1 > threshold
+}
+
+// jsonParams are the query parameters whose values are JSON documents
+var jsonParams = map[string]bool{
+	"query":         true,
+	"order_by":      true,
+	"counts_filter": true,
+}
+
+/*
+queryBody - Split a URL built by queryURL into its path and a JSON body
+holding the same parameters, as accepted by POST to the query end-points.
+
+The query, order_by and counts_filter parameters hold JSON and are embedded
+as is, anything else is sent as a string, as it would be in the URL.
+*/
+func queryBody(fullURL string) (string, []byte, error) {
+	path, rawQuery := fullURL, ""
+	if i := strings.Index(fullURL, "?"); i >= 0 {
+		path, rawQuery = fullURL[:i], fullURL[i+1:]
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", nil, err
+	}
+
+	params := make(map[string]interface{}, len(values))
+	for key := range values {
+		value := values.Get(key)
+		if jsonParams[key] {
+			params[key] = json.RawMessage(value)
 		} else {
-			queryString += `,`
+			params[key] = value
 		}
-		queryString += `"` + query + `"`
 	}
+	requestJSON, err := json.Marshal(params)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, requestJSON, nil
+}
+
+/*
+BuildQueryInventory will take in the fact and the query
+*/
+func (server *Server) BuildQueryInventory(queryElements ...string) (*[]Inventory, error) {
+	var query ast.Query
 	if len(queryElements) > 0 {
-		queryString += "]"
+		queryJSON, err := json.Marshal(queryElements)
+		if err != nil {
+			return nil, err
+		}
+		query = ast.Raw(queryJSON)
 	}
 
-	log.Debugf("queryString=%s\n", queryString)
-	return server.QueryInventory(queryString, nil)
+	log.Debugf("query=%v\n", query)
+	return server.QueryInventory(query, nil)
 }
 
 /*
@@ -135,45 +326,49 @@ QueryInventory - Query the PuppetDB instance inventory end-point.
 
 More details here: https://puppet.com/docs/puppetdb/5.2/api/query/v4/inventory.html
 */
-func (server *Server) QueryInventory(queryString string, requestBody body) (*[]Inventory, error) {
-	url := fmt.Sprintf("pdb/query/v4/inventory%v", queryString)
+func (server *Server) QueryInventory(query ast.Query, opts *QueryOptions) (*[]Inventory, error) {
+	return server.QueryInventoryContext(context.Background(), query, opts)
+}
 
-	log.Debugf("url=%s\n", url)
-	if requestBody != nil {
-		server.Body = requestBody
+/*
+QueryInventoryContext - QueryInventory with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryInventoryContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Inventory, error) {
+	url, err := queryURL("pdb/query/v4/inventory", query, opts, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := server.Query(url)
+	log.Debugf("url=%s\n", url)
+
+	body, err := server.query(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var inventory []Inventory
-	json.Unmarshal(body, &inventory)
+	if err := decodeResponse(url, body, &inventory); err != nil {
+		return nil, err
+	}
 
-	return &inventory, err
+	return &inventory, nil
 }
 
 /*
 QueryFact will take in the fact and the query
 */
 func (server *Server) QueryFact(fact string, queryElements ...string) (*[]Fact, error) {
-	queryString := fact
-	for i, query := range queryElements {
-		if i == 0 {
-			queryString += "?query=["
-		} else {
-			queryString += `,`
-		}
-		queryString += `"` + query + `"`
-
-	}
+	var query ast.Query
 	if len(queryElements) > 0 {
-		queryString += "]"
+		queryJSON, err := json.Marshal(queryElements)
+		if err != nil {
+			return nil, err
+		}
+		query = ast.Raw(queryJSON)
 	}
 
-	log.Debugf("queryString=%s\n", queryString)
-	return server.QueryFacts(queryString, nil)
+	log.Debugf("query=%v\n", query)
+	return server.QueryFactsByName(fact, query, nil)
 }
 
 /*
@@ -181,24 +376,32 @@ QueryFacts - Query the PuppetDB instance facts end-point.
 
 More details here: https://puppet.com/docs/puppetdb/5.2/api/query/v4/facts.html
 */
-func (server *Server) QueryFacts(queryString string, requestBody body) (*[]Fact, error) {
-	//url := fmt.Sprintf("pdb/query/v4/facts?%v", queryString)
-	url := fmt.Sprintf("pdb/query/v4/facts/%v", queryString)
+func (server *Server) QueryFacts(query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	return server.QueryFactsContext(context.Background(), query, opts)
+}
 
-	log.Debugf("url=%s\n", url)
-	if requestBody != nil {
-		server.Body = requestBody
+/*
+QueryFactsContext - QueryFacts with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactsContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	url, err := queryURL("pdb/query/v4/facts", query, opts, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := server.Query(url)
+	log.Debugf("url=%s\n", url)
+
+	body, err := server.query(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var facts []Fact
-	json.Unmarshal(body, &facts)
+	if err := decodeResponse(url, body, &facts); err != nil {
+		return nil, err
+	}
 
-	return &facts, err
+	return &facts, nil
 }
 
 /*
@@ -208,18 +411,30 @@ ie: Only the certnmae field will differ
 
 More details here: https://puppet.com/docs/puppetdb/5.2/api/query/v4/facts.html#pdbqueryv4factsfact-namevalue
 */
-func (server *Server) QueryFactsByName(name string, queryString string) (*[]Fact, error) {
-	url := fmt.Sprintf("pdb/query/v4/facts/%v/%v", name, queryString)
+func (server *Server) QueryFactsByName(name string, query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	return server.QueryFactsByNameContext(context.Background(), name, query, opts)
+}
 
-	body, err := server.Query(url)
+/*
+QueryFactsByNameContext - QueryFactsByName with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactsByNameContext(ctx context.Context, name string, query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	url, err := queryURL(fmt.Sprintf("pdb/query/v4/facts/%v", url.PathEscape(name)), query, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := server.query(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var facts []Fact
-	json.Unmarshal(body, &facts)
+	if err := decodeResponse(url, body, &facts); err != nil {
+		return nil, err
+	}
 
-	return &facts, err
+	return &facts, nil
 }
 
 /*
@@ -227,18 +442,31 @@ QueryFactsByNameValue - Query the PuppetDB instance facts end-point.
 
 More details here: http://docs.puppetlabs.com/puppetdb/1.6/api/query/v3/facts.html#get-v3factsnamevalue
 */
-func (server *Server) QueryFactsByNameValue(name string, value string, queryString string) (*[]Fact, error) {
-	url := fmt.Sprintf("pdb/query/v4/facts/%v/%v?%v", name, value, queryString)
+func (server *Server) QueryFactsByNameValue(name string, value string, query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	return server.QueryFactsByNameValueContext(context.Background(), name, value, query, opts)
+}
 
-	body, err := server.Query(url)
+/*
+QueryFactsByNameValueContext - QueryFactsByNameValue with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactsByNameValueContext(ctx context.Context, name string, value string, query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	path := fmt.Sprintf("pdb/query/v4/facts/%v/%v", url.PathEscape(name), url.PathEscape(value))
+	url, err := queryURL(path, query, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := server.query(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var facts []Fact
-	json.Unmarshal(body, &facts)
+	if err := decodeResponse(url, body, &facts); err != nil {
+		return nil, err
+	}
 
-	return &facts, err
+	return &facts, nil
 }
 
 /*
@@ -246,18 +474,30 @@ QueryResources - Query the PuppetDB instance resources end-point.
 
 More details here: http://docs.puppetlabs.com/puppetdb/1.6/api/query/v3/resources.html#get-v3resources
 */
-func (server *Server) QueryResources(queryString string) (*[]CatalogResource, error) {
-	url := fmt.Sprintf("pdb/query/v4/resources/%v", queryString)
+func (server *Server) QueryResources(query ast.Query, opts *QueryOptions) (*[]CatalogResource, error) {
+	return server.QueryResourcesContext(context.Background(), query, opts)
+}
 
-	body, err := server.Query(url)
+/*
+QueryResourcesContext - QueryResources with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryResourcesContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]CatalogResource, error) {
+	url, err := queryURL("pdb/query/v4/resources", query, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := server.query(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var resources []CatalogResource
-	json.Unmarshal(body, &resources)
+	if err := decodeResponse(url, body, &resources); err != nil {
+		return nil, err
+	}
 
-	return &resources, err
+	return &resources, nil
 }
 
 /*
@@ -265,18 +505,30 @@ QueryNodes - Query the PuppetDB instance nodes end-point.
 
 More details here: https://puppet.com/docs/puppetdb/5.2/api/query/v4/nodes.html
 */
-func (server *Server) QueryNodes(queryString string) (*[]Node, error) {
-	url := fmt.Sprintf("pdb/query/v4/nodes/%v", queryString)
+func (server *Server) QueryNodes(query ast.Query, opts *QueryOptions) (*[]Node, error) {
+	return server.QueryNodesContext(context.Background(), query, opts)
+}
 
-	body, err := server.Query(url)
+/*
+QueryNodesContext - QueryNodes with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryNodesContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Node, error) {
+	url, err := queryURL("pdb/query/v4/nodes", query, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := server.query(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var nodes []Node
-	json.Unmarshal(body, &nodes)
+	if err := decodeResponse(url, body, &nodes); err != nil {
+		return nil, err
+	}
 
-	return &nodes, err
+	return &nodes, nil
 }
 
 /*
@@ -284,18 +536,30 @@ QueryReports - Query the PuppetDB instance reports end-point.
 
 More details here: http://docs.puppetlabs.com/puppetdb/1.6/api/query/v3/reports.html#get-v3reports
 */
-func (server *Server) QueryReports(queryString string) (*[]Report, error) {
-	url := fmt.Sprintf("pdb/query/v4/reports/%v", queryString)
+func (server *Server) QueryReports(query ast.Query, opts *QueryOptions) (*[]Report, error) {
+	return server.QueryReportsContext(context.Background(), query, opts)
+}
 
-	body, err := server.Query(url)
+/*
+QueryReportsContext - QueryReports with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryReportsContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Report, error) {
+	url, err := queryURL("pdb/query/v4/reports", query, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := server.query(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var reports []Report
-	json.Unmarshal(body, &reports)
+	if err := decodeResponse(url, body, &reports); err != nil {
+		return nil, err
+	}
 
-	return &reports, err
+	return &reports, nil
 }
 
 /*
@@ -303,54 +567,96 @@ QueryEvents - Query the PuppetDB instance events end-point.
 
 More details here: https://puppet.com/docs/puppetdb/5.2/api/query/v4/events.html
 */
-func (server *Server) QueryEvents(queryString string) (*[]Event, error) {
-	url := fmt.Sprintf("pdb/query/v4/events/%v", queryString)
+func (server *Server) QueryEvents(query ast.Query, opts *QueryOptions) (*[]Event, error) {
+	return server.QueryEventsContext(context.Background(), query, opts)
+}
+
+/*
+QueryEventsContext - QueryEvents with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEventsContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Event, error) {
+	url, err := queryURL("pdb/query/v4/events", query, opts, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	body, err := server.Query(url)
+	body, err := server.query(ctx, url, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	var event []Event
-	json.Unmarshal(body, &event)
+	if err := decodeResponse(url, body, &event); err != nil {
+		return nil, err
+	}
 
-	return &event, err
+	return &event, nil
 }
 
 /*
 QueryEventCounts - Query the PuppetDB instance event-counts end-point.
 
+summarizeBy is required by PuppetDB, and is one of certname, containing_class
+or resource.
+
 More details here: https://puppet.com/docs/puppetdb/5.2/api/query/v4/event-counts.html
 */
-func (server *Server) QueryEventCounts(queryString string) (*EventCounts, error) {
-	url := fmt.Sprintf("pdb/query/v4/event-counts?%v", queryString)
+func (server *Server) QueryEventCounts(query ast.Query, summarizeBy string, opts *QueryOptions) (*[]EventCounts, error) {
+	return server.QueryEventCountsContext(context.Background(), query, summarizeBy, opts)
+}
 
-	body, err := server.Query(url)
+/*
+QueryEventCountsContext - QueryEventCounts with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEventCountsContext(ctx context.Context, query ast.Query, summarizeBy string, opts *QueryOptions) (*[]EventCounts, error) {
+	url, err := queryURL("pdb/query/v4/event-counts", query, opts, url.Values{"summarize_by": {summarizeBy}})
 	if err != nil {
 		return nil, err
 	}
 
-	var eventCounts EventCounts
-	json.Unmarshal(body, &eventCounts)
+	body, err := server.query(ctx, url, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	return &eventCounts, err
+	var eventCounts []EventCounts
+	if err := decodeResponse(url, body, &eventCounts); err != nil {
+		return nil, err
+	}
+
+	return &eventCounts, nil
 }
 
 /*
 QueryAggregateEventCounts - Query the PuppetDB instance aggregate-event-counts end-point.
 
+summarizeBy is required by PuppetDB, and is one or more of certname,
+containing_class or resource, separated by commas.
+
 More details here: https://puppet.com/docs/puppetdb/5.2/api/query/v4/aggregate-event-counts.html
 */
-func (server *Server) QueryAggregateEventCounts(queryString string) (*AggregateEventCounts, error) {
-	url := fmt.Sprintf("pdb/query/v4/aggregate-event-counts?%v", queryString)
+func (server *Server) QueryAggregateEventCounts(query ast.Query, summarizeBy string) (*[]AggregateEventCounts, error) {
+	return server.QueryAggregateEventCountsContext(context.Background(), query, summarizeBy)
+}
+
+/*
+QueryAggregateEventCountsContext - QueryAggregateEventCounts with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryAggregateEventCountsContext(ctx context.Context, query ast.Query, summarizeBy string) (*[]AggregateEventCounts, error) {
+	url, err := queryURL("pdb/query/v4/aggregate-event-counts", query, nil, url.Values{"summarize_by": {summarizeBy}})
+	if err != nil {
+		return nil, err
+	}
 
-	body, err := server.Query(url)
+	body, err := server.query(ctx, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var aec AggregateEventCounts
-	json.Unmarshal(body, &aec)
+	var aec []AggregateEventCounts
+	if err := decodeResponse(url, body, &aec); err != nil {
+		return nil, err
+	}
 
-	return &aec, err
+	return &aec, nil
 }
@@ -0,0 +1,76 @@
+package puppetdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+QueryEdges - Query the PuppetDB instance edges end-point, returning the
+relationships between resources in the most recent catalog of each node.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/edges.html
+*/
+func (server *Server) QueryEdges(query ast.Query, opts *QueryOptions) (*[]Edge, error) {
+	return server.QueryEdgesContext(context.Background(), query, opts)
+}
+
+/*
+QueryEdgesContext - QueryEdges with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEdgesContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Edge, error) {
+	var edges []Edge
+	if err := server.queryInto(ctx, "pdb/query/v4/edges", query, opts, &edges); err != nil {
+		return nil, err
+	}
+	return &edges, nil
+}
+
+/*
+QueryCatalogEdges - Query the edges of the most recent catalog of a node, such
+as to reconstruct its dependency graph without fetching the whole catalog.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/catalogs.html#pdbqueryv4catalogscertnameedges
+*/
+func (server *Server) QueryCatalogEdges(certname string, query ast.Query, opts *QueryOptions) (*[]Edge, error) {
+	return server.QueryCatalogEdgesContext(context.Background(), certname, query, opts)
+}
+
+/*
+QueryCatalogEdgesContext - QueryCatalogEdges with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryCatalogEdgesContext(ctx context.Context, certname string, query ast.Query, opts *QueryOptions) (*[]Edge, error) {
+	var edges []Edge
+	if err := server.queryInto(ctx, catalogPath(certname, "edges"), query, opts, &edges); err != nil {
+		return nil, err
+	}
+	return &edges, nil
+}
+
+/*
+QueryCatalogResources - Query the resources of the most recent catalog of a node.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/catalogs.html#pdbqueryv4catalogscertnameresources
+*/
+func (server *Server) QueryCatalogResources(certname string, query ast.Query, opts *QueryOptions) (*[]CatalogResource, error) {
+	return server.QueryCatalogResourcesContext(context.Background(), certname, query, opts)
+}
+
+/*
+QueryCatalogResourcesContext - QueryCatalogResources with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryCatalogResourcesContext(ctx context.Context, certname string, query ast.Query, opts *QueryOptions) (*[]CatalogResource, error) {
+	var resources []CatalogResource
+	if err := server.queryInto(ctx, catalogPath(certname, "resources"), query, opts, &resources); err != nil {
+		return nil, err
+	}
+	return &resources, nil
+}
+
+// catalogPath returns the path of a catalog sub-resource, such as its edges
+func catalogPath(certname string, entity string) string {
+	return fmt.Sprintf("pdb/query/v4/catalogs/%v/%v", url.PathEscape(certname), entity)
+}
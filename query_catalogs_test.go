@@ -0,0 +1,53 @@
+package puppetdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryCatalogEdges(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pdb/query/v4/catalogs/foo.example.com/edges":
+			w.Write([]byte(`[{
+				"certname": "foo.example.com",
+				"relationship": "required-by",
+				"source_type": "Package",
+				"source_title": "ntp",
+				"target_type": "Service",
+				"target_title": "ntpd"
+			}]`))
+		case "/pdb/query/v4/catalogs/foo.example.com/resources":
+			w.Write([]byte(`[{"type": "Service", "title": "ntpd", "exported": false, "tags": ["ntp"], "parameters": {"ensure": "running"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	edges, err := server.QueryCatalogEdges("foo.example.com", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*edges) != 1 {
+		t.Fatalf("unexpected edges %+v", edges)
+	}
+	want := CatalogEdge{
+		Source:       CatalogResourceSpec{"Package", "ntp"},
+		Target:       CatalogResourceSpec{"Service", "ntpd"},
+		Relationship: "required-by",
+	}
+	if got := (*edges)[0].CatalogEdge(); got != want {
+		t.Errorf("CatalogEdge() = %+v, want %+v", got, want)
+	}
+
+	resources, err := server.QueryCatalogResources("foo.example.com", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*resources) != 1 || (*resources)[0].Spec() != want.Target {
+		t.Errorf("unexpected resources %+v", resources)
+	}
+}
@@ -0,0 +1,142 @@
+package puppetdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+QueryEnvironments - Query the PuppetDB instance environments end-point.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/environments.html#pdbqueryv4environments
+*/
+func (server *Server) QueryEnvironments(query ast.Query, opts *QueryOptions) (*[]Environment, error) {
+	return server.QueryEnvironmentsContext(context.Background(), query, opts)
+}
+
+/*
+QueryEnvironmentsContext - QueryEnvironments with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEnvironmentsContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Environment, error) {
+	var environments []Environment
+	if err := server.queryInto(ctx, "pdb/query/v4/environments", query, opts, &environments); err != nil {
+		return nil, err
+	}
+	return &environments, nil
+}
+
+/*
+QueryEnvironment - Query a single environment.
+
+An unknown environment is reported as an error satisfying IsNotFound.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/environments.html#pdbqueryv4environmentsenvironment
+*/
+func (server *Server) QueryEnvironment(name string) (*Environment, error) {
+	return server.QueryEnvironmentContext(context.Background(), name)
+}
+
+/*
+QueryEnvironmentContext - QueryEnvironment with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEnvironmentContext(ctx context.Context, name string) (*Environment, error) {
+	path := fmt.Sprintf("pdb/query/v4/environments/%v", url.PathEscape(name))
+	body, err := server.QueryContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var environment Environment
+	if err := decodeResponse(path, body, &environment); err != nil {
+		return nil, err
+	}
+	return &environment, nil
+}
+
+/*
+QueryEnvironmentFacts - Query the facts of nodes in an environment.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/environments.html#pdbqueryv4environmentsenvironmenttype
+*/
+func (server *Server) QueryEnvironmentFacts(environment string, query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	return server.QueryEnvironmentFactsContext(context.Background(), environment, query, opts)
+}
+
+/*
+QueryEnvironmentFactsContext - QueryEnvironmentFacts with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEnvironmentFactsContext(ctx context.Context, environment string, query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	var facts []Fact
+	if err := server.queryInto(ctx, environmentPath(environment, "facts"), query, opts, &facts); err != nil {
+		return nil, err
+	}
+	return &facts, nil
+}
+
+/*
+QueryEnvironmentReports - Query the reports of runs in an environment.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/environments.html#pdbqueryv4environmentsenvironmenttype
+*/
+func (server *Server) QueryEnvironmentReports(environment string, query ast.Query, opts *QueryOptions) (*[]Report, error) {
+	return server.QueryEnvironmentReportsContext(context.Background(), environment, query, opts)
+}
+
+/*
+QueryEnvironmentReportsContext - QueryEnvironmentReports with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEnvironmentReportsContext(ctx context.Context, environment string, query ast.Query, opts *QueryOptions) (*[]Report, error) {
+	var reports []Report
+	if err := server.queryInto(ctx, environmentPath(environment, "reports"), query, opts, &reports); err != nil {
+		return nil, err
+	}
+	return &reports, nil
+}
+
+/*
+QueryEnvironmentResources - Query the catalog resources of nodes in an environment.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/environments.html#pdbqueryv4environmentsenvironmenttype
+*/
+func (server *Server) QueryEnvironmentResources(environment string, query ast.Query, opts *QueryOptions) (*[]CatalogResource, error) {
+	return server.QueryEnvironmentResourcesContext(context.Background(), environment, query, opts)
+}
+
+/*
+QueryEnvironmentResourcesContext - QueryEnvironmentResources with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEnvironmentResourcesContext(ctx context.Context, environment string, query ast.Query, opts *QueryOptions) (*[]CatalogResource, error) {
+	var resources []CatalogResource
+	if err := server.queryInto(ctx, environmentPath(environment, "resources"), query, opts, &resources); err != nil {
+		return nil, err
+	}
+	return &resources, nil
+}
+
+/*
+QueryEnvironmentEvents - Query the resource events of runs in an environment.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/environments.html#pdbqueryv4environmentsenvironmenttype
+*/
+func (server *Server) QueryEnvironmentEvents(environment string, query ast.Query, opts *QueryOptions) (*[]Event, error) {
+	return server.QueryEnvironmentEventsContext(context.Background(), environment, query, opts)
+}
+
+/*
+QueryEnvironmentEventsContext - QueryEnvironmentEvents with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryEnvironmentEventsContext(ctx context.Context, environment string, query ast.Query, opts *QueryOptions) (*[]Event, error) {
+	var events []Event
+	if err := server.queryInto(ctx, environmentPath(environment, "events"), query, opts, &events); err != nil {
+		return nil, err
+	}
+	return &events, nil
+}
+
+// environmentPath returns the path of an environment sub-resource, such as its facts
+func environmentPath(environment string, entity string) string {
+	return fmt.Sprintf("pdb/query/v4/environments/%v/%v", url.PathEscape(environment), entity)
+}
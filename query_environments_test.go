@@ -0,0 +1,59 @@
+package puppetdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+func TestQueryEnvironments(t *testing.T) {
+	var gotQuery string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("query")
+		switch r.URL.EscapedPath() {
+		case "/pdb/query/v4/environments":
+			w.Write([]byte(`[{"name": "production"}, {"name": "feature/x"}]`))
+		case "/pdb/query/v4/environments/production":
+			w.Write([]byte(`{"name": "production"}`))
+		case "/pdb/query/v4/environments/feature%2Fx/facts":
+			w.Write([]byte(`[{"certname": "foo.example.com", "environment": "feature/x", "name": "kernel", "value": "Linux"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "No information is known about environment"}`))
+		}
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	environments, err := server.QueryEnvironments(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*environments) != 2 || (*environments)[1].Name != "feature/x" {
+		t.Errorf("unexpected environments %+v", environments)
+	}
+
+	environment, err := server.QueryEnvironment("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if environment.Name != "production" {
+		t.Errorf("unexpected environment %+v", environment)
+	}
+	if _, err := server.QueryEnvironment("unknown"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	facts, err := server.QueryEnvironmentFacts("feature/x", ast.Equals{Field: "name", Value: "kernel"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["=","name","kernel"]`; gotQuery != want {
+		t.Errorf("query = %s, want %s", gotQuery, want)
+	}
+	if len(*facts) != 1 || (*facts)[0].Value.String() != "Linux" {
+		t.Errorf("unexpected facts %+v", facts)
+	}
+}
@@ -0,0 +1,52 @@
+package puppetdb
+
+import (
+	"context"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+QueryFactContents - Query the PuppetDB instance fact-contents end-point, which
+returns every leaf value of structured facts along with its path. Use
+ast.PathMatches and ast.PathEquals to select paths, for example:
+
+	server.QueryFactContents(ast.PathMatches("networking", "interfaces", ".*", "ip"), nil)
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/fact-contents.html
+*/
+func (server *Server) QueryFactContents(query ast.Query, opts *QueryOptions) (*[]FactContent, error) {
+	return server.QueryFactContentsContext(context.Background(), query, opts)
+}
+
+/*
+QueryFactContentsContext - QueryFactContents with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactContentsContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]FactContent, error) {
+	var contents []FactContent
+	if err := server.queryInto(ctx, "pdb/query/v4/fact-contents", query, opts, &contents); err != nil {
+		return nil, err
+	}
+	return &contents, nil
+}
+
+/*
+QueryFactPaths - Query the PuppetDB instance fact-paths end-point, which
+returns every fact path known across all nodes and the type of its values.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/fact-paths.html
+*/
+func (server *Server) QueryFactPaths(query ast.Query, opts *QueryOptions) (*[]FactPathType, error) {
+	return server.QueryFactPathsContext(context.Background(), query, opts)
+}
+
+/*
+QueryFactPathsContext - QueryFactPaths with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactPathsContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]FactPathType, error) {
+	var paths []FactPathType
+	if err := server.queryInto(ctx, "pdb/query/v4/fact-paths", query, opts, &paths); err != nil {
+		return nil, err
+	}
+	return &paths, nil
+}
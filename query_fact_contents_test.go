@@ -0,0 +1,60 @@
+package puppetdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+func TestQueryFactContents(t *testing.T) {
+	var gotQuery string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("query")
+		switch r.URL.Path {
+		case "/pdb/query/v4/fact-contents":
+			w.Write([]byte(`[{
+				"certname": "foo.example.com",
+				"environment": "production",
+				"name": "networking",
+				"path": ["networking", "interfaces", "eth0", "bindings", 0, "address"],
+				"value": "10.0.0.5"
+			}]`))
+		case "/pdb/query/v4/fact-paths":
+			w.Write([]byte(`[{"path": ["processors", "models", 0], "type": "string"}]`))
+		}
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	contents, err := server.QueryFactContents(ast.PathMatches("networking", "interfaces", ".*", "bindings", ".*", "address"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["~>","path",["networking","interfaces",".*","bindings",".*","address"]]`; gotQuery != want {
+		t.Errorf("query = %s, want %s", gotQuery, want)
+	}
+	if len(*contents) != 1 {
+		t.Fatalf("unexpected contents %+v", contents)
+	}
+	content := (*contents)[0]
+	if want := (FactPath{"networking", "interfaces", "eth0", "bindings", 0, "address"}); !reflect.DeepEqual(content.Path, want) {
+		t.Errorf("path = %#v, want %#v", content.Path, want)
+	}
+	if content.Path.String() != "networking.interfaces.eth0.bindings.0.address" || content.Value.String() != "10.0.0.5" {
+		t.Errorf("unexpected content %v = %v", content.Path, content.Value)
+	}
+
+	paths, err := server.QueryFactPaths(ast.PathEquals("processors", "models", 0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["=","path",["processors","models",0]]`; gotQuery != want {
+		t.Errorf("query = %s, want %s", gotQuery, want)
+	}
+	if len(*paths) != 1 || (*paths)[0].Type != "string" || (*paths)[0].Path[2] != 0 {
+		t.Errorf("unexpected paths %+v", paths)
+	}
+}
@@ -0,0 +1,99 @@
+package puppetdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+QueryFactsets - Query the PuppetDB instance factsets end-point, returning the
+whole fact set of each node as one document.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/factsets.html
+*/
+func (server *Server) QueryFactsets(query ast.Query, opts *QueryOptions) (*[]Factset, error) {
+	return server.QueryFactsetsContext(context.Background(), query, opts)
+}
+
+/*
+QueryFactsetsContext - QueryFactsets with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactsetsContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Factset, error) {
+	var factsets []Factset
+	if err := server.queryInto(ctx, "pdb/query/v4/factsets", query, opts, &factsets); err != nil {
+		return nil, err
+	}
+	return &factsets, nil
+}
+
+/*
+QueryFactset - Query the most recent fact set of a single node.
+
+An unknown node is reported as an error satisfying IsNotFound.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/factsets.html#pdbqueryv4factsetscertname
+*/
+func (server *Server) QueryFactset(certname string) (*Factset, error) {
+	return server.QueryFactsetContext(context.Background(), certname)
+}
+
+/*
+QueryFactsetContext - QueryFactset with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactsetContext(ctx context.Context, certname string) (*Factset, error) {
+	path := fmt.Sprintf("pdb/query/v4/factsets/%v", url.PathEscape(certname))
+	body, err := server.QueryContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Depending on the version, PuppetDB answers with the factset or a list of it
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		var factset Factset
+		if err := decodeResponse(path, body, &factset); err != nil {
+			return nil, err
+		}
+		return &factset, nil
+	}
+
+	var factsets []Factset
+	if err := decodeResponse(path, body, &factsets); err != nil {
+		return nil, err
+	}
+	if len(factsets) == 0 {
+		return nil, &APIError{
+			StatusCode: http.StatusNotFound,
+			Endpoint:   path,
+			URL:        server.BaseURL + path,
+			Message:    "no factset for " + certname,
+		}
+	}
+	return &factsets[0], nil
+}
+
+/*
+QueryFactsetFacts - Query the facts in the most recent fact set of a node,
+one row per fact.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/factsets.html#pdbqueryv4factsetscertnamefacts
+*/
+func (server *Server) QueryFactsetFacts(certname string, query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	return server.QueryFactsetFactsContext(context.Background(), certname, query, opts)
+}
+
+/*
+QueryFactsetFactsContext - QueryFactsetFacts with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryFactsetFactsContext(ctx context.Context, certname string, query ast.Query, opts *QueryOptions) (*[]Fact, error) {
+	path := fmt.Sprintf("pdb/query/v4/factsets/%v/facts", url.PathEscape(certname))
+	var facts []Fact
+	if err := server.queryInto(ctx, path, query, opts, &facts); err != nil {
+		return nil, err
+	}
+	return &facts, nil
+}
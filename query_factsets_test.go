@@ -0,0 +1,66 @@
+package puppetdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryFactsets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pdb/query/v4/factsets", "/pdb/query/v4/factsets/foo.example.com":
+			w.Write([]byte(`[{
+				"certname": "foo.example.com",
+				"environment": "production",
+				"timestamp": "2020-09-13T12:26:40.000Z",
+				"producer_timestamp": "2020-09-13T12:26:39.000Z",
+				"producer": "puppet.example.com",
+				"hash": "b6cb0ddc6c4a22f3ec8e1e3bc4ac6fa3f00fbd7a",
+				"facts": {"href": "/pdb/query/v4/factsets/foo.example.com/facts", "data": [
+					{"name": "os", "value": {"release": {"major": "8"}}},
+					{"name": "processorcount", "value": 4}
+				]}
+			}]`))
+		case "/pdb/query/v4/factsets/foo.example.com/facts":
+			w.Write([]byte(`[{"certname": "foo.example.com", "environment": "production", "name": "processorcount", "value": 4}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	factsets, err := server.QueryFactsets(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*factsets) != 1 || (*factsets)[0].Producer != "puppet.example.com" {
+		t.Fatalf("unexpected factsets %+v", factsets)
+	}
+	if major, err := (*factsets)[0].Values().Get("os.release.major"); err != nil || major.String() != "8" {
+		t.Errorf("os.release.major = %v, %v", major, err)
+	}
+
+	factset, err := server.QueryFactset("foo.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if factset.Hash != "b6cb0ddc6c4a22f3ec8e1e3bc4ac6fa3f00fbd7a" || len(factset.Facts.Data) != 2 {
+		t.Errorf("unexpected factset %+v", factset)
+	}
+	if _, err := server.QueryFactset("unknown.example.com"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	facts, err := server.QueryFactsetFacts("foo.example.com", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*facts) != 1 || (*facts)[0].Environment != "production" {
+		t.Errorf("unexpected facts %+v", facts)
+	}
+	if n, err := (*facts)[0].Value.AsInt(); err != nil || n != 4 {
+		t.Errorf("AsInt() = %v, %v", n, err)
+	}
+}
@@ -0,0 +1,197 @@
+package puppetdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+QueryOptions - Paging options shared by the query end-points.
+
+A nil *QueryOptions, or the zero value, sends no paging parameters at all.
+PuppetDB only guarantees a stable order between pages when OrderBy is set.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/paging.html
+*/
+type QueryOptions struct {
+	// Maximum number of results to return, 0 for no limit
+	Limit int
+	// Number of results to skip before returning any
+	Offset int
+	// Fields to sort the results by
+	OrderBy []ast.OrderBy
+	// Ask PuppetDB for the total number of results in the X-Records header
+	IncludeTotal bool
+	// Send the query by POST with a JSON body, regardless of its size
+	ForcePOST bool
+}
+
+func (opts *QueryOptions) forcePOST() bool {
+	return opts != nil && opts.ForcePOST
+}
+
+type orderByParam struct {
+	Field string `json:"field"`
+	Order string `json:"order"`
+}
+
+func (opts *QueryOptions) values() (url.Values, error) {
+	values := url.Values{}
+	if opts == nil {
+		return values, nil
+	}
+	if opts.Limit > 0 {
+		values.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		values.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	if len(opts.OrderBy) > 0 {
+		orderBy := make([]orderByParam, len(opts.OrderBy))
+		for i, o := range opts.OrderBy {
+			orderBy[i] = orderByParam{Field: o.Field, Order: "asc"}
+			if o.Descending {
+				orderBy[i].Order = "desc"
+			}
+		}
+		orderByJSON, err := json.Marshal(orderBy)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("order_by", string(orderByJSON))
+	}
+	if opts.IncludeTotal {
+		values.Set("include_total", "true")
+	}
+	return values, nil
+}
+
+/*
+TotalRecords - Read the total result count from the X-Records header of a
+query response.
+
+PuppetDB only sends the header when the query was made with IncludeTotal, ok
+is false when it is missing or malformed.
+*/
+func TotalRecords(resp *http.Response) (total int, ok bool) {
+	total, err := strconv.Atoi(resp.Header.Get("X-Records"))
+	if err != nil {
+		return 0, false
+	}
+	return total, true
+}
+
+/*
+Pager - Fetches the results of a query one page at a time.
+
+Use NewPager to create one, then call Next until it returns false:
+
+	pager := server.NewPager("pdb/query/v4/nodes", nil, 100, &puppetdb.QueryOptions{
+		OrderBy: []ast.OrderBy{{Field: "certname"}},
+	})
+	for {
+		var nodes []puppetdb.Node
+		more, err := pager.Next(&nodes)
+		if err != nil || !more {
+			break
+		}
+		...
+	}
+*/
+type Pager struct {
+	server   *Server
+	path     string
+	query    ast.Query
+	options  QueryOptions
+	pageSize int
+	total    int
+	hasTotal bool
+	done     bool
+}
+
+/*
+NewPager - Create a Pager over the query end-point at path, such as
+"pdb/query/v4/reports".
+
+Each page holds at most pageSize results, or everything at once when pageSize
+is not positive. opts may be nil, otherwise its Offset is used as the starting
+point and its Limit is ignored.
+*/
+func (server *Server) NewPager(path string, query ast.Query, pageSize int, opts *QueryOptions) *Pager {
+	pager := &Pager{
+		server:   server,
+		path:     path,
+		query:    query,
+		pageSize: pageSize,
+	}
+	if opts != nil {
+		pager.options = *opts
+	}
+	pager.options.IncludeTotal = true
+	return pager
+}
+
+/*
+Next - Fetch the next page of results and decode it into v, which should be
+a pointer to a slice.
+
+Returns false once the result set has been exhausted, with v holding an
+empty page if PuppetDB returned one.
+*/
+func (p *Pager) Next(v interface{}) (bool, error) {
+	return p.NextContext(context.Background(), v)
+}
+
+/*
+NextContext - Next with a context for cancellation and deadlines.
+*/
+func (p *Pager) NextContext(ctx context.Context, v interface{}) (bool, error) {
+	if p.done {
+		return false, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return false, fmt.Errorf("puppetdb: Pager needs a pointer to a slice, got %T", v)
+	}
+
+	p.options.Limit = p.pageSize
+	url, err := queryURL(p.path, p.query, &p.options, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := p.server.queryResponse(ctx, url, &p.options)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if total, ok := TotalRecords(resp); ok {
+		p.total, p.hasTotal = total, true
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, err
+	}
+	rows := reflect.ValueOf(v).Elem().Len()
+
+	p.options.Offset += rows
+	if p.pageSize <= 0 || rows < p.pageSize || (p.hasTotal && p.options.Offset >= p.total) {
+		p.done = true
+	}
+	return rows > 0, nil
+}
+
+/*
+Total - The total number of results reported by PuppetDB, available once
+the first page has been fetched.
+*/
+func (p *Pager) Total() (total int, ok bool) {
+	return p.total, p.hasTotal
+}
@@ -0,0 +1,88 @@
+package puppetdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+func TestQueryOptionsValues(t *testing.T) {
+	opts := &QueryOptions{
+		Limit:        10,
+		Offset:       20,
+		OrderBy:      []ast.OrderBy{{Field: "certname"}, {Field: "report_timestamp", Descending: true}},
+		IncludeTotal: true,
+	}
+	url, err := queryURL("pdb/query/v4/nodes", nil, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pdb/query/v4/nodes?include_total=true&limit=10&offset=20&order_by=" +
+		"%5B%7B%22field%22%3A%22certname%22%2C%22order%22%3A%22asc%22%7D%2C%7B%22field%22%3A%22report_timestamp%22%2C%22order%22%3A%22desc%22%7D%5D"
+	if url != want {
+		t.Errorf("queryURL() = %s, want %s", url, want)
+	}
+
+	var nilOpts *QueryOptions
+	if url, _ := queryURL("pdb/query/v4/nodes", nil, nilOpts, nil); url != "pdb/query/v4/nodes" {
+		t.Errorf("queryURL() with nil options = %s", url)
+	}
+}
+
+func TestPager(t *testing.T) {
+	certnames := []string{"a", "b", "c", "d", "e"}
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		end := offset + limit
+		if end > len(certnames) {
+			end = len(certnames)
+		}
+		var nodes []map[string]string
+		for _, certname := range certnames[offset:end] {
+			nodes = append(nodes, map[string]string{"certname": certname})
+		}
+		if r.URL.Query().Get("include_total") == "true" {
+			w.Header().Set("X-Records", strconv.Itoa(len(certnames)))
+		}
+		json.NewEncoder(w).Encode(nodes)
+	}))
+	defer ts.Close()
+
+	server := NewServer(ts.URL + "/")
+	pager := server.NewPager("pdb/query/v4/nodes", nil, 2, nil)
+	var seen []string
+	for {
+		var page []map[string]string
+		more, err := pager.Next(&page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !more {
+			break
+		}
+		for _, node := range page {
+			seen = append(seen, node["certname"])
+		}
+	}
+	if len(seen) != len(certnames) {
+		t.Errorf("paged %v, want %v", seen, certnames)
+	}
+	if requests != 3 {
+		t.Errorf("made %d requests, want 3", requests)
+	}
+	if total, ok := pager.Total(); !ok || total != len(certnames) {
+		t.Errorf("Total() = %d, %v", total, ok)
+	}
+
+	var node map[string]string
+	if _, err := server.NewPager("pdb/query/v4/nodes", nil, 2, nil).Next(&node); err == nil {
+		t.Error("expected an error paging into a map")
+	}
+}
@@ -0,0 +1,75 @@
+package puppetdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+QueryPackages - Query the PuppetDB instance packages end-point, listing each
+distinct package installed across all nodes.
+
+Package data is only collected when package inventory is enabled, as it is in
+Puppet Enterprise.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/packages.html#pdbqueryv4packages
+*/
+func (server *Server) QueryPackages(query ast.Query, opts *QueryOptions) (*[]Package, error) {
+	return server.QueryPackagesContext(context.Background(), query, opts)
+}
+
+/*
+QueryPackagesContext - QueryPackages with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryPackagesContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Package, error) {
+	var packages []Package
+	if err := server.queryInto(ctx, "pdb/query/v4/packages", query, opts, &packages); err != nil {
+		return nil, err
+	}
+	return &packages, nil
+}
+
+/*
+QueryPackageInventory - Query the PuppetDB instance package-inventory
+end-point, listing the packages installed on each node.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/packages.html#pdbqueryv4package-inventory
+*/
+func (server *Server) QueryPackageInventory(query ast.Query, opts *QueryOptions) (*[]Package, error) {
+	return server.QueryPackageInventoryContext(context.Background(), query, opts)
+}
+
+/*
+QueryPackageInventoryContext - QueryPackageInventory with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryPackageInventoryContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Package, error) {
+	var packages []Package
+	if err := server.queryInto(ctx, "pdb/query/v4/package-inventory", query, opts, &packages); err != nil {
+		return nil, err
+	}
+	return &packages, nil
+}
+
+/*
+QueryPackageInventoryByCertname - Query the packages installed on a single node.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/packages.html#pdbqueryv4package-inventorycertname
+*/
+func (server *Server) QueryPackageInventoryByCertname(certname string, query ast.Query, opts *QueryOptions) (*[]Package, error) {
+	return server.QueryPackageInventoryByCertnameContext(context.Background(), certname, query, opts)
+}
+
+/*
+QueryPackageInventoryByCertnameContext - QueryPackageInventoryByCertname with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryPackageInventoryByCertnameContext(ctx context.Context, certname string, query ast.Query, opts *QueryOptions) (*[]Package, error) {
+	path := fmt.Sprintf("pdb/query/v4/package-inventory/%v", url.PathEscape(certname))
+	var packages []Package
+	if err := server.queryInto(ctx, path, query, opts, &packages); err != nil {
+		return nil, err
+	}
+	return &packages, nil
+}
@@ -0,0 +1,38 @@
+package puppetdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryPackages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pdb/query/v4/packages":
+			w.Write([]byte(`[{"package_name": "openssl", "version": "1.1.1k-1", "provider": "yum"}]`))
+		case "/pdb/query/v4/package-inventory/foo.example.com":
+			w.Write([]byte(`[{"certname": "foo.example.com", "package_name": "openssl", "version": "1.1.1k-1", "provider": "yum"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	packages, err := server.QueryPackages(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Package{PackageName: "openssl", Version: "1.1.1k-1", Provider: "yum"}); len(*packages) != 1 || (*packages)[0] != want {
+		t.Errorf("unexpected packages %+v", packages)
+	}
+
+	inventory, err := server.QueryPackageInventoryByCertname("foo.example.com", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*inventory) != 1 || (*inventory)[0].Certname != "foo.example.com" {
+		t.Errorf("unexpected package inventory %+v", inventory)
+	}
+}
@@ -0,0 +1,66 @@
+package puppetdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/pql"
+)
+
+/*
+QueryPQL - Query the PuppetDB instance root query end-point with a PQL query.
+
+Each row of the result is returned as a generic map, as the columns depend on
+the entity and projection of the query. The query is parsed locally first so
+syntax errors are returned as a *pql.SyntaxError without contacting PuppetDB.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/pql.html
+*/
+func (server *Server) QueryPQL(query string) ([]map[string]interface{}, error) {
+	return server.QueryPQLContext(context.Background(), query)
+}
+
+/*
+QueryPQLContext - QueryPQL with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryPQLContext(ctx context.Context, query string) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	if err := server.QueryPQLIntoContext(ctx, query, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+/*
+QueryPQLInto - Query the PuppetDB instance root query end-point with a PQL
+query, decoding the result into v.
+
+v should be a pointer to a slice of a type matching the query, for example
+*[]Node for a nodes query without a projection.
+*/
+func (server *Server) QueryPQLInto(query string, v interface{}) error {
+	return server.QueryPQLIntoContext(context.Background(), query, v)
+}
+
+/*
+QueryPQLIntoContext - QueryPQLInto with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryPQLIntoContext(ctx context.Context, query string, v interface{}) error {
+	if _, err := pql.Parse(query); err != nil {
+		return err
+	}
+
+	requestJSON, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return err
+	}
+
+	url := "pdb/query/v4"
+	body, err := server.request(ctx, "POST", url, bytes.NewReader(requestJSON))
+	if err != nil {
+		return err
+	}
+
+	return decodeResponse(url, body, v)
+}
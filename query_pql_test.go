@@ -0,0 +1,37 @@
+package puppetdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/pql"
+)
+
+func TestQueryPQL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]string
+		json.NewDecoder(r.Body).Decode(&request)
+		if r.Method != "POST" || r.URL.Path != "/pdb/query/v4" || request["query"] != `nodes[certname] {}` {
+			t.Errorf("unexpected request %s %s %v", r.Method, r.URL.Path, request)
+		}
+		w.Write([]byte(`[{"certname": "foo"}]`))
+	}))
+	defer ts.Close()
+
+	server := NewServer(ts.URL + "/")
+	rows, err := server.QueryPQL(`nodes[certname] {}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["certname"] != "foo" {
+		t.Errorf("unexpected rows %v", rows)
+	}
+
+	if _, err := server.QueryPQL(`nodes[certname] {`); err == nil {
+		t.Error("expected a syntax error")
+	} else if _, ok := err.(*pql.SyntaxError); !ok {
+		t.Errorf("expected *pql.SyntaxError, got %T", err)
+	}
+}
@@ -0,0 +1,123 @@
+package puppetdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+QueryProducers - Query the PuppetDB instance producers end-point, listing the
+Puppet Servers that have submitted catalogs, facts or reports.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/producers.html#pdbqueryv4producers
+*/
+func (server *Server) QueryProducers(query ast.Query, opts *QueryOptions) (*[]Producer, error) {
+	return server.QueryProducersContext(context.Background(), query, opts)
+}
+
+/*
+QueryProducersContext - QueryProducers with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryProducersContext(ctx context.Context, query ast.Query, opts *QueryOptions) (*[]Producer, error) {
+	var producers []Producer
+	if err := server.queryInto(ctx, "pdb/query/v4/producers", query, opts, &producers); err != nil {
+		return nil, err
+	}
+	return &producers, nil
+}
+
+/*
+QueryProducer - Query a single producer.
+
+An unknown producer is reported as an error satisfying IsNotFound.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/producers.html#pdbqueryv4producersproducer
+*/
+func (server *Server) QueryProducer(name string) (*Producer, error) {
+	return server.QueryProducerContext(context.Background(), name)
+}
+
+/*
+QueryProducerContext - QueryProducer with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryProducerContext(ctx context.Context, name string) (*Producer, error) {
+	path := fmt.Sprintf("pdb/query/v4/producers/%v", url.PathEscape(name))
+	body, err := server.QueryContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var producer Producer
+	if err := decodeResponse(path, body, &producer); err != nil {
+		return nil, err
+	}
+	return &producer, nil
+}
+
+/*
+QueryProducerCatalogs - Query the catalogs compiled by a producer.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/producers.html#pdbqueryv4producersproducercatalogs
+*/
+func (server *Server) QueryProducerCatalogs(producer string, query ast.Query, opts *QueryOptions) (*[]Catalog, error) {
+	return server.QueryProducerCatalogsContext(context.Background(), producer, query, opts)
+}
+
+/*
+QueryProducerCatalogsContext - QueryProducerCatalogs with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryProducerCatalogsContext(ctx context.Context, producer string, query ast.Query, opts *QueryOptions) (*[]Catalog, error) {
+	var catalogs []Catalog
+	if err := server.queryInto(ctx, producerPath(producer, "catalogs"), query, opts, &catalogs); err != nil {
+		return nil, err
+	}
+	return &catalogs, nil
+}
+
+/*
+QueryProducerFactsets - Query the fact sets submitted by a producer.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/producers.html#pdbqueryv4producersproducerfactsets
+*/
+func (server *Server) QueryProducerFactsets(producer string, query ast.Query, opts *QueryOptions) (*[]Factset, error) {
+	return server.QueryProducerFactsetsContext(context.Background(), producer, query, opts)
+}
+
+/*
+QueryProducerFactsetsContext - QueryProducerFactsets with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryProducerFactsetsContext(ctx context.Context, producer string, query ast.Query, opts *QueryOptions) (*[]Factset, error) {
+	var factsets []Factset
+	if err := server.queryInto(ctx, producerPath(producer, "factsets"), query, opts, &factsets); err != nil {
+		return nil, err
+	}
+	return &factsets, nil
+}
+
+/*
+QueryProducerReports - Query the reports submitted by a producer.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/producers.html#pdbqueryv4producersproducerreports
+*/
+func (server *Server) QueryProducerReports(producer string, query ast.Query, opts *QueryOptions) (*[]Report, error) {
+	return server.QueryProducerReportsContext(context.Background(), producer, query, opts)
+}
+
+/*
+QueryProducerReportsContext - QueryProducerReports with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryProducerReportsContext(ctx context.Context, producer string, query ast.Query, opts *QueryOptions) (*[]Report, error) {
+	var reports []Report
+	if err := server.queryInto(ctx, producerPath(producer, "reports"), query, opts, &reports); err != nil {
+		return nil, err
+	}
+	return &reports, nil
+}
+
+// producerPath returns the path of a producer sub-resource, such as its reports
+func producerPath(producer string, entity string) string {
+	return fmt.Sprintf("pdb/query/v4/producers/%v/%v", url.PathEscape(producer), entity)
+}
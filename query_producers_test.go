@@ -0,0 +1,93 @@
+package puppetdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryProducers(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		switch r.URL.EscapedPath() {
+		case "/pdb/query/v4/producers":
+			w.Write([]byte(`[{"name": "puppet.example.com"}]`))
+		case "/pdb/query/v4/producers/puppet%2Fa":
+			w.Write([]byte(`{"name": "puppet/a"}`))
+		case "/pdb/query/v4/producers/puppet%2Fa/catalogs":
+			w.Write([]byte(`[{"certname": "foo.example.com", "producer": "puppet/a", "hash": "c1",
+				"edges": {"href": "/pdb/query/v4/catalogs/foo.example.com/edges"},
+				"resources": {"href": "/pdb/query/v4/catalogs/foo.example.com/resources"}}]`))
+		case "/pdb/query/v4/producers/puppet%2Fa/factsets":
+			w.Write([]byte(`[{"certname": "foo.example.com", "producer": "puppet/a", "hash": "f1",
+				"facts": {"href": "/pdb/query/v4/factsets/foo.example.com/facts"}}]`))
+		case "/pdb/query/v4/producers/puppet%2Fa/reports":
+			w.Write([]byte(`[{"certname": "foo.example.com", "producer": "puppet/a", "hash": "r1", "status": "failed"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	producers, err := server.QueryProducers(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*producers) != 1 || (*producers)[0].Name != "puppet.example.com" {
+		t.Errorf("unexpected producers %+v", producers)
+	}
+
+	producer, err := server.QueryProducer("puppet/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if producer.Name != "puppet/a" {
+		t.Errorf("unexpected producer %+v", producer)
+	}
+	if _, err := server.QueryProducer("unknown"); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	catalogs, err := server.QueryProducerCatalogs("puppet/a", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*catalogs) != 1 || (*catalogs)[0].Hash != "c1" || (*catalogs)[0].Edges.Href == "" {
+		t.Errorf("unexpected catalogs %+v", catalogs)
+	}
+
+	factsets, err := server.QueryProducerFactsets("puppet/a", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*factsets) != 1 || (*factsets)[0].Hash != "f1" || (*factsets)[0].Facts.Href == "" {
+		t.Errorf("unexpected factsets %+v", factsets)
+	}
+
+	reports, err := server.QueryProducerReports("puppet/a", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*reports) != 1 || (*reports)[0].Hash != "r1" || (*reports)[0].Status != "failed" {
+		t.Errorf("unexpected reports %+v", reports)
+	}
+
+	want := []string{
+		"/pdb/query/v4/producers",
+		"/pdb/query/v4/producers/puppet%2Fa",
+		"/pdb/query/v4/producers/unknown",
+		"/pdb/query/v4/producers/puppet%2Fa/catalogs",
+		"/pdb/query/v4/producers/puppet%2Fa/factsets",
+		"/pdb/query/v4/producers/puppet%2Fa/reports",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("requested %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("request %d to %s, want %s", i, paths[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package puppetdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+/*
+QueryReportEvents - Query the resource events of a single report, identified
+by its Hash.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/reports.html#pdbqueryv4reportshashevents
+*/
+func (server *Server) QueryReportEvents(hash string, query ast.Query, opts *QueryOptions) (*[]Event, error) {
+	return server.QueryReportEventsContext(context.Background(), hash, query, opts)
+}
+
+/*
+QueryReportEventsContext - QueryReportEvents with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryReportEventsContext(ctx context.Context, hash string, query ast.Query, opts *QueryOptions) (*[]Event, error) {
+	var events []Event
+	if err := server.queryInto(ctx, reportPath(hash, "events"), query, opts, &events); err != nil {
+		return nil, err
+	}
+	return &events, nil
+}
+
+/*
+QueryReportLogs - Query the log lines of a single report, identified by its
+Hash, such as to see why a run failed.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/reports.html#pdbqueryv4reportshashlogs
+*/
+func (server *Server) QueryReportLogs(hash string) (*[]Log, error) {
+	return server.QueryReportLogsContext(context.Background(), hash)
+}
+
+/*
+QueryReportLogsContext - QueryReportLogs with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryReportLogsContext(ctx context.Context, hash string) (*[]Log, error) {
+	var logs []Log
+	if err := server.queryInto(ctx, reportPath(hash, "logs"), nil, nil, &logs); err != nil {
+		return nil, err
+	}
+	return &logs, nil
+}
+
+/*
+QueryReportMetrics - Query the metrics of a single report, identified by its
+Hash.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/reports.html#pdbqueryv4reportshashmetrics
+*/
+func (server *Server) QueryReportMetrics(hash string) (*[]Metric, error) {
+	return server.QueryReportMetricsContext(context.Background(), hash)
+}
+
+/*
+QueryReportMetricsContext - QueryReportMetrics with a context for cancellation and deadlines.
+*/
+func (server *Server) QueryReportMetricsContext(ctx context.Context, hash string) (*[]Metric, error) {
+	var metrics []Metric
+	if err := server.queryInto(ctx, reportPath(hash, "metrics"), nil, nil, &metrics); err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+// reportPath returns the path of a report sub-resource, such as its logs
+func reportPath(hash string, entity string) string {
+	return fmt.Sprintf("pdb/query/v4/reports/%v/%v", url.PathEscape(hash), entity)
+}
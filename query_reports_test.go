@@ -0,0 +1,57 @@
+package puppetdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryReportLogsAndMetrics(t *testing.T) {
+	const hash = "32c821673e647b0650717db467abc51d9949fd9a"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pdb/query/v4/reports/" + hash + "/logs":
+			w.Write([]byte(`[{
+				"file": null,
+				"line": null,
+				"level": "err",
+				"message": "Could not find package ntp",
+				"source": "/Stage[main]/Ntp/Package[ntp]/ensure",
+				"tags": ["err", "ntp"],
+				"time": "2020-09-13T12:26:41.123Z"
+			}]`))
+		case "/pdb/query/v4/reports/" + hash + "/metrics":
+			w.Write([]byte(`[{"category": "time", "name": "total", "value": 12.5}]`))
+		case "/pdb/query/v4/reports/" + hash + "/events":
+			w.Write([]byte(`[{"certname": "foo.example.com", "report": "` + hash + `", "status": "failure", "resource_type": "Package", "resource_title": "ntp"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	logs, err := server.QueryReportLogs(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*logs) != 1 || (*logs)[0].Level != "err" || (*logs)[0].Time.IsZero() {
+		t.Errorf("unexpected logs %+v", logs)
+	}
+
+	metrics, err := server.QueryReportMetrics(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Metric{Category: "time", Name: "total", Value: 12.5}); len(*metrics) != 1 || (*metrics)[0] != want {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+
+	events, err := server.QueryReportEvents(hash, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*events) != 1 || (*events)[0].Report != hash || (*events)[0].Status != "failure" {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
@@ -0,0 +1,150 @@
+package puppetdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
+)
+
+func TestQueryNodesContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	server := NewServer(ts.URL + "/")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := server.QueryNodesContext(ctx, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestQueryPOST(t *testing.T) {
+	var method string
+	var params map[string]json.RawMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, params = r.Method, nil
+		if r.Method == "POST" {
+			if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+				t.Error(err)
+			}
+		}
+		w.Write([]byte(`[{"certname": "node0"}]`))
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	opts := &QueryOptions{Limit: 5, OrderBy: []ast.OrderBy{{Field: "certname"}}}
+	if _, err := server.QueryNodes(ast.Equals{Field: "certname", Value: "node0"}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if method != "GET" {
+		t.Errorf("short query sent by %s", method)
+	}
+
+	certnames := make(ast.Array, 2000)
+	for i := range certnames {
+		certnames[i] = fmt.Sprintf("node%d.example.com", i)
+	}
+	query := ast.In{Fields: []string{"certname"}, Source: certnames}
+	if _, err := server.QueryNodes(query, opts); err != nil {
+		t.Fatal(err)
+	}
+	if method != "POST" {
+		t.Fatalf("long query sent by %s", method)
+	}
+	queryJSON, _ := ast.Marshal(query)
+	if string(params["query"]) != queryJSON || string(params["limit"]) != `"5"` ||
+		string(params["order_by"]) != `[{"field":"certname","order":"asc"}]` {
+		t.Errorf("unexpected POST body %s", params)
+	}
+
+	opts.ForcePOST, opts.IncludeTotal = true, true
+	if _, err := server.QueryFacts(nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if method != "POST" || string(params["limit"]) != `"5"` || string(params["include_total"]) != `"true"` {
+		t.Errorf("forced query sent by %s with %s", method, params)
+	}
+}
+
+func TestQueryEventCounts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("summarize_by") == "" {
+			t.Error("no summarize_by parameter")
+		}
+		switch r.URL.Path {
+		case "/pdb/query/v4/event-counts":
+			w.Write([]byte(`[ {
+  "subject_type" : "resource",
+  "subject" : {
+    "type" : "File",
+    "title" : "/etc/hosts"
+  },
+  "failures" : 1,
+  "successes" : 2,
+  "noops" : 0,
+  "skips" : 3
+}, {
+  "subject_type" : "resource",
+  "subject" : {
+    "type" : "Service",
+    "title" : "sshd"
+  },
+  "failures" : 0,
+  "successes" : 1,
+  "noops" : 0,
+  "skips" : 0
+} ]`))
+		case "/pdb/query/v4/aggregate-event-counts":
+			w.Write([]byte(`[ {
+  "successes" : 3,
+  "failures" : 1,
+  "noops" : 0,
+  "skips" : 3,
+  "total" : 2,
+  "summarize_by" : "resource"
+} ]`))
+		}
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	counts, err := server.QueryEventCounts(ast.Equals{Field: "certname", Value: "foo.example.com"}, "resource", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := EventCounts{
+		SubjectType: "resource",
+		Subject:     EventCountSubject{Type: "File", Title: "/etc/hosts"},
+		Failures:    1,
+		Successes:   2,
+		Skips:       3,
+	}
+	if len(*counts) != 2 || (*counts)[0] != want {
+		t.Errorf("unexpected event counts %+v", counts)
+	}
+
+	aggregate, err := server.QueryAggregateEventCounts(nil, "resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*aggregate) != 1 || (*aggregate)[0].SummarizeBy != "resource" || (*aggregate)[0].Total != 2 {
+		t.Errorf("unexpected aggregate event counts %+v", aggregate)
+	}
+}
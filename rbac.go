@@ -0,0 +1,258 @@
+package puppetdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultTokenLifetime matches the lifetime Authenticate used with puppet-access
+const DefaultTokenLifetime = 4 * time.Hour
+
+/*
+Credentials - A Puppet Enterprise RBAC username and password.
+*/
+type Credentials struct {
+	Username string
+	Password string
+}
+
+/*
+CredentialProvider - Supplies RBAC credentials when a new token is needed,
+for example from a secret store or an interactive prompt.
+*/
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// Credentials returns the static credentials, satisfying CredentialProvider
+func (c Credentials) Credentials(ctx context.Context) (Credentials, error) {
+	return c, nil
+}
+
+/*
+CredentialsFunc - Adapter to use an ordinary function as a CredentialProvider.
+*/
+type CredentialsFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials calls f(ctx)
+func (f CredentialsFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+/*
+RBACClient - Acquires Puppet Enterprise RBAC tokens natively, without the
+puppet-access tool.
+
+Tokens are cached in TokenFile, in the same format puppet-access uses, so
+the two can share a token. Since the file only holds the token, its expiry is
+estimated from the file's modification time plus Lifetime. Caching is best
+effort: a token that can't be saved, such as on a read-only filesystem, is
+still used from memory.
+
+More details here: https://puppet.com/docs/pe/latest/rbac_token_auth_intro.html
+*/
+type RBACClient struct {
+	// RBAC API URL, such as https://puppet.example.com:4433/rbac-api
+	ServiceURL    string
+	HTTPTransport http.RoundTripper
+	HTTPTimeout   time.Duration
+	// Where to find credentials when a new token is needed. When nil only
+	// an existing cached token can be used
+	Credentials CredentialProvider
+	// Lifetime requested for new tokens, DefaultTokenLifetime when 0
+	Lifetime time.Duration
+	// Refresh tokens this long before they are due to expire
+	RefreshBefore time.Duration
+	// Token cache file, defaults to ~/.puppetlabs/token
+	TokenFile string
+	// Keep tokens in memory only, never reading or writing TokenFile
+	DisableTokenCache bool
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+/*
+NewRBACClient - Create an RBACClient for the RBAC API at serviceURL.
+*/
+func NewRBACClient(serviceURL string, credentials CredentialProvider) *RBACClient {
+	return &RBACClient{
+		ServiceURL:    strings.TrimSuffix(serviceURL, "/"),
+		HTTPTimeout:   time.Second * 30,
+		Credentials:   credentials,
+		Lifetime:      DefaultTokenLifetime,
+		RefreshBefore: 5 * time.Minute,
+	}
+}
+
+/*
+DefaultTokenFile - The token file used by puppet-access, ~/.puppetlabs/token
+*/
+func DefaultTokenFile() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".puppetlabs", "token"), nil
+}
+
+func (c *RBACClient) tokenFile() (string, error) {
+	if c.DisableTokenCache {
+		return "", errors.New("rbac: token cache disabled")
+	}
+	if c.TokenFile != "" {
+		return c.TokenFile, nil
+	}
+	return DefaultTokenFile()
+}
+
+func (c *RBACClient) lifetime() time.Duration {
+	if c.Lifetime <= 0 {
+		return DefaultTokenLifetime
+	}
+	return c.Lifetime
+}
+
+func (c *RBACClient) fresh(expires time.Time) bool {
+	return time.Now().Add(c.RefreshBefore).Before(expires)
+}
+
+/*
+Token - Return a valid token, from memory, the token file or a new login, in
+that order of preference.
+*/
+func (c *RBACClient) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && c.fresh(c.expires) {
+		return c.token, nil
+	}
+
+	if file, err := c.tokenFile(); err == nil {
+		if info, err := os.Stat(file); err == nil && c.fresh(info.ModTime().Add(c.lifetime())) {
+			token, err := ioutil.ReadFile(file)
+			if err == nil && len(bytes.TrimSpace(token)) > 0 {
+				c.token = string(bytes.TrimSpace(token))
+				c.expires = info.ModTime().Add(c.lifetime())
+				return c.token, nil
+			}
+		}
+	}
+
+	return c.login(ctx)
+}
+
+/*
+Login - Request a new token from the RBAC API, regardless of any cached one,
+and save it to the token file when possible.
+*/
+func (c *RBACClient) Login(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.login(ctx)
+}
+
+func (c *RBACClient) login(ctx context.Context) (string, error) {
+	if c.Credentials == nil {
+		return "", errors.New("rbac: no valid token cached and no credentials configured")
+	}
+	credentials, err := c.Credentials.Credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	requestJSON, err := json.Marshal(map[string]string{
+		"login":    credentials.Username,
+		"password": credentials.Password,
+		"lifetime": fmt.Sprintf("%ds", int64(c.lifetime()/time.Second)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	tokenURL := c.ServiceURL + "/v1/auth/token"
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, bytes.NewReader(requestJSON))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Transport: c.HTTPTransport, Timeout: c.HTTPTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", newAPIError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var tokenResponse struct {
+		Token string `json:"token"`
+	}
+	if err := decodeResponse(tokenURL, body, &tokenResponse); err != nil {
+		return "", err
+	}
+	if tokenResponse.Token == "" {
+		return "", errors.New("rbac: no token in response from " + tokenURL)
+	}
+
+	c.token = tokenResponse.Token
+	c.expires = time.Now().Add(c.lifetime())
+	if err := c.saveToken(); err != nil {
+		log.Debugf("rbac: not caching token: %v\n", err)
+	}
+	return c.token, nil
+}
+
+func (c *RBACClient) saveToken() error {
+	file, err := c.tokenFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, []byte(c.token), 0600)
+}
+
+/*
+Invalidate - Forget the current token and remove the token file, for use
+when PuppetDB has rejected it.
+*/
+func (c *RBACClient) Invalidate() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.token = ""
+	if c.DisableTokenCache {
+		return nil
+	}
+	file, err := c.tokenFile()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package puppetdb
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRBACClientToken(t *testing.T) {
+	logins := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]string
+		json.NewDecoder(r.Body).Decode(&request)
+		if r.URL.Path != "/rbac-api/v1/auth/token" || request["login"] != "admin" || request["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		logins++
+		w.Write([]byte(`{"token": "0123456789abcdef"}`))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "rbac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, ".puppetlabs", "token")
+
+	client := NewRBACClient(ts.URL+"/rbac-api", Credentials{Username: "admin", Password: "secret"})
+	client.TokenFile = tokenFile
+	token, err := client.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "0123456789abcdef" {
+		t.Errorf("unexpected token %q", token)
+	}
+	if saved, _ := ioutil.ReadFile(tokenFile); string(saved) != token {
+		t.Errorf("token file holds %q", saved)
+	}
+
+	// A second client picks the token up from the file without logging in
+	cached := NewRBACClient(ts.URL+"/rbac-api", nil)
+	cached.TokenFile = tokenFile
+	if token, err := cached.Token(context.Background()); err != nil || token != "0123456789abcdef" {
+		t.Errorf("expected the cached token, got %q, %v", token, err)
+	}
+	if logins != 1 {
+		t.Errorf("logged in %d times, want 1", logins)
+	}
+
+	if err := cached.Invalidate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cached.Token(context.Background()); err == nil {
+		t.Error("expected an error without a cached token or credentials")
+	}
+
+	bad := NewRBACClient(ts.URL+"/rbac-api", Credentials{Username: "admin", Password: "wrong"})
+	bad.TokenFile = tokenFile
+	if _, err := bad.Token(context.Background()); !IsUnauthorized(err) {
+		t.Errorf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestRBACClientUnwritableCache(t *testing.T) {
+	logins := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		w.Write([]byte(`{"token": "0123456789abcdef"}`))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "rbac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// A file where the cache directory should be can't be written below
+	blocker := filepath.Join(dir, ".puppetlabs")
+	if err := ioutil.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewRBACClient(ts.URL+"/rbac-api", Credentials{Username: "admin", Password: "secret"})
+	client.TokenFile = filepath.Join(blocker, "token")
+	for i := 0; i < 2; i++ {
+		if token, err := client.Token(context.Background()); err != nil || token != "0123456789abcdef" {
+			t.Fatalf("expected a token despite the unwritable cache, got %q, %v", token, err)
+		}
+	}
+	if logins != 1 {
+		t.Errorf("logged in %d times, want 1", logins)
+	}
+
+	tokenFile := filepath.Join(dir, "token")
+	uncached := NewRBACClient(ts.URL+"/rbac-api", Credentials{Username: "admin", Password: "secret"})
+	uncached.TokenFile = tokenFile
+	uncached.DisableTokenCache = true
+	if _, err := uncached.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(tokenFile); !os.IsNotExist(err) {
+		t.Errorf("token file written with caching disabled: %v", err)
+	}
+}
+
+func TestRBACClientLiteral(t *testing.T) {
+	var lifetimes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]string
+		json.NewDecoder(r.Body).Decode(&request)
+		lifetimes = append(lifetimes, request["lifetime"])
+		w.Write([]byte(`{"token": "0123456789abcdef"}`))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "rbac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A client built without NewRBACClient falls back to the default lifetime
+	client := &RBACClient{
+		ServiceURL:  ts.URL + "/rbac-api",
+		Credentials: Credentials{Username: "admin", Password: "secret"},
+		TokenFile:   filepath.Join(dir, "token"),
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.Token(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(lifetimes) != 1 || lifetimes[0] != "14400s" {
+		t.Errorf("unexpected logins requesting lifetimes %q", lifetimes)
+	}
+}
@@ -1,114 +1,240 @@
 package puppetdb
 
+import "time"
+
 /*
-ReportWireFormat - A representation of a report wire format.
+ReportWireFormat - A representation of a report wire format, version 8.
+
+Fields the format allows to be null are pointers, sent as an explicit null
+when nil.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/wire_format/report_format.html#report-interchange-format
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/report_format_v8.html
 */
 type ReportWireFormat struct {
-	Certname             string          `json:"certname"`
-	PuppetVersion        string          `json:"puppet-version"`
-	ReportFormat         int             `json:"report-format"`
-	ConfigurationVersion string          `json:"configuration-version"`
-	StartTime            string          `json:"start-time"`
-	EndTime              string          `json:"end-time"`
-	ResourceEvents       []ResourceEvent `json:"resource-events"`
-	TransactionUUID      string          `json:"transaction-uuid"`
+	Certname             string    `json:"certname"`
+	Environment          string    `json:"environment"`
+	PuppetVersion        string    `json:"puppet_version"`
+	ReportFormat         int       `json:"report_format"`
+	ConfigurationVersion string    `json:"configuration_version"`
+	StartTime            time.Time `json:"start_time"`
+	EndTime              time.Time `json:"end_time"`
+	ProducerTimestamp    time.Time `json:"producer_timestamp"`
+	Producer             *string   `json:"producer"`
+	TransactionUUID      string    `json:"transaction_uuid"`
+	CatalogUUID          string    `json:"catalog_uuid"`
+	CodeID               *string   `json:"code_id"`
+	JobID                string    `json:"job_id,omitempty"`
+	CachedCatalogStatus  string    `json:"cached_catalog_status"`
+	// Outcome of the run: changed, unchanged or failed
+	Status           string           `json:"status"`
+	Noop             bool             `json:"noop"`
+	NoopPending      bool             `json:"noop_pending"`
+	CorrectiveChange bool             `json:"corrective_change"`
+	Resources        []ReportResource `json:"resources"`
+	Metrics          []Metric         `json:"metrics"`
+	Logs             []Log            `json:"logs"`
+}
+
+/*
+ReportResource - A resource managed during a run, with the events that
+happened to it.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/report_format_v8.html#data-type-resource
+*/
+type ReportResource struct {
+	ResourceType     string          `json:"resource_type"`
+	ResourceTitle    string          `json:"resource_title"`
+	Skipped          bool            `json:"skipped"`
+	Timestamp        time.Time       `json:"timestamp"`
+	File             *string         `json:"file"`
+	Line             *int            `json:"line"`
+	ContainmentPath  []string        `json:"containment_path"`
+	CorrectiveChange bool            `json:"corrective_change"`
+	Events           []ResourceEvent `json:"events"`
+}
+
+/*
+ResourceEvent - A representation of a resource event from a report.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/report_format_v8.html#data-type-event
+*/
+type ResourceEvent struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+	Name      *string   `json:"name"`
+	Property  string    `json:"property"`
+	// Old and new values of the property, each any JSON value
+	OldValue         interface{} `json:"old_value"`
+	NewValue         interface{} `json:"new_value"`
+	Message          string      `json:"message"`
+	CorrectiveChange bool        `json:"corrective_change"`
+}
+
+/*
+Metric - A measurement from a run, such as the time spent on each resource
+type or the number of changed resources.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/report_format_v8.html#data-type-metric
+*/
+type Metric struct {
+	Category string  `json:"category"`
+	Name     string  `json:"name"`
+	Value    float64 `json:"value"`
+}
+
+/*
+Log - A message logged during a run.
+
+More details here: https://puppet.com/docs/puppetdb/latest/api/wire_format/report_format_v8.html#data-type-log
+*/
+type Log struct {
+	File    *string   `json:"file"`
+	Line    *int      `json:"line"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	Source  string    `json:"source"`
+	Tags    []string  `json:"tags"`
+	Time    time.Time `json:"time"`
 }
 
 /*
 Report struct
 A representation of a report query response.
 
-This differs just ever so slightly from the wire format, so we should one day
-into providing an interface{} to handle these.
+Resource events, metrics and logs are expanded in line, with a link to query
+them separately.
 
-More details here: http://docs.puppetlabs.com/puppetdb/1.6/api/query/v3/reports.html#get-v3reports
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/reports.html#response-format
 */
 type Report struct {
-	Certname             string `json:"certname"`
-	PuppetVersion        string `json:"puppet-version"`
-	ReportFormat         int    `json:"report-format"`
-	ConfigurationVersion string `json:"configuration-version"`
-	StartTime            string `json:"start-time"`
-	EndTime              string `json:"end-time"`
-	TransactionUUID      string `json:"transaction-uuid"`
-	Hash                 string `json:"hash"`
+	Certname             string          `json:"certname"`
+	Hash                 string          `json:"hash"`
+	Environment          string          `json:"environment"`
+	Status               string          `json:"status"`
+	Noop                 bool            `json:"noop"`
+	NoopPending          bool            `json:"noop_pending"`
+	CorrectiveChange     bool            `json:"corrective_change"`
+	PuppetVersion        string          `json:"puppet_version"`
+	ReportFormat         int             `json:"report_format"`
+	ConfigurationVersion string          `json:"configuration_version"`
+	StartTime            time.Time       `json:"start_time"`
+	EndTime              time.Time       `json:"end_time"`
+	ProducerTimestamp    time.Time       `json:"producer_timestamp"`
+	Producer             string          `json:"producer"`
+	ReceiveTime          time.Time       `json:"receive_time"`
+	TransactionUUID      string          `json:"transaction_uuid"`
+	CatalogUUID          string          `json:"catalog_uuid"`
+	CodeID               string          `json:"code_id"`
+	JobID                string          `json:"job_id"`
+	CachedCatalogStatus  string          `json:"cached_catalog_status"`
+	LatestReport         bool            `json:"latest_report?"`
+	ResourceEvents       ExpandedEvents  `json:"resource_events"`
+	Metrics              ExpandedMetrics `json:"metrics"`
+	Logs                 ExpandedLogs    `json:"logs"`
 }
 
 /*
-ResourceEvent - A representation of a resource even from a report.
+ExpandedEvents - Resource events included in a report query response, along
+with the URL path to query them on their own.
+*/
+type ExpandedEvents struct {
+	Href string  `json:"href"`
+	Data []Event `json:"data"`
+}
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/wire_format/report_format.html#data-type-resource-event
+/*
+ExpandedMetrics - Metrics included in a report query response, along with
+the URL path to query them on their own.
 */
-type ResourceEvent struct {
-	ResourceType    string   `json:"resource-type"`
-	ResourceTitle   string   `json:"resource-title"`
-	Property        string   `json:"property"`
-	Timestamp       string   `json:"timestamp"`
-	Status          string   `json:"status"`
-	OldValue        string   `json:"old-value"`
-	NewValue        string   `json:"new-value"`
-	Message         string   `json:"message"`
-	File            string   `json:"file"`
-	Line            int      `json:"line"`
-	ContainmentPath []string `json:"containment-path"`
+type ExpandedMetrics struct {
+	Href string   `json:"href"`
+	Data []Metric `json:"data"`
+}
+
+/*
+ExpandedLogs - Logs included in a report query response, along with the URL
+path to query them on their own.
+*/
+type ExpandedLogs struct {
+	Href string `json:"href"`
+	Data []Log  `json:"data"`
 }
 
 /*
 Event struct
 A representation of a resource event from a report, as returned by a query.
 
-This differs ever so slightly from ResourceEvent, it would be nice to combine
-these somehow in the future.
+Events embedded in a report only carry the fields describing the event
+itself, the rest are filled in by the events end-point.
 
-More details here: http://docs.puppetlabs.com/puppetdb/1.6/api/query/v3/events.html#get-v3events
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/events.html#response-format
 */
 type Event struct {
-	Certname          string   `json:"certname"`
-	Report            string   `json:"report"`
-	RunStartTime      string   `json:"run-start-time"`
-	RunEndTime        string   `json:"run-end-time"`
-	ReportReceiveTime string   `json:"report-receive-time"`
-	LatestReport      bool     `json:"latest-report?"`
-	ResourceType      string   `json:"resource-type"`
-	ResourceTitle     string   `json:"resource-title"`
-	Property          string   `json:"property"`
-	Timestamp         string   `json:"timestamp"`
-	Status            string   `json:"status"`
-	OldValue          string   `json:"old-value"`
-	NewValue          string   `json:"new-value"`
-	Message           string   `json:"message"`
-	File              string   `json:"file"`
-	Line              int      `json:"line"`
-	ContainmentPath   []string `json:"containment-path"`
+	Certname             string    `json:"certname"`
+	Report               string    `json:"report"`
+	Environment          string    `json:"environment"`
+	ConfigurationVersion string    `json:"configuration_version"`
+	RunStartTime         time.Time `json:"run_start_time"`
+	RunEndTime           time.Time `json:"run_end_time"`
+	ReportReceiveTime    time.Time `json:"report_receive_time"`
+	LatestReport         bool      `json:"latest_report?"`
+	ResourceType         string    `json:"resource_type"`
+	ResourceTitle        string    `json:"resource_title"`
+	ContainingClass      string    `json:"containing_class"`
+	ContainmentPath      []string  `json:"containment_path"`
+	Property             string    `json:"property"`
+	Name                 string    `json:"name"`
+	Timestamp            time.Time `json:"timestamp"`
+	Status               string    `json:"status"`
+	// Old and new values of the property, each any JSON value
+	OldValue         interface{} `json:"old_value"`
+	NewValue         interface{} `json:"new_value"`
+	Message          string      `json:"message"`
+	File             string      `json:"file"`
+	Line             int         `json:"line"`
+	CorrectiveChange bool        `json:"corrective_change"`
 }
 
 /*
 EventCounts struct
-Response data structure for event-counts query end-point.
+Counts of resource events for one subject, as returned by the event-counts
+query end-point, which answers with one of these per subject.
 
-More details here: http://docs.puppetlabs.com/puppetdb/latest/api/query/v3/event-counts.html#get-v3event-counts
+More details here: https://puppet.com/docs/puppetdb/latest/api/query/v4/event-counts.html#response-format
 */
 type EventCounts struct {
-	SubjectType string `json:"subject-type"`
-	Subject     string `json:"subject"`
-	Failures    string `json:"failures"`
-	Successes   string `json:"successes"`
-	Noops       string `json:"noops"`
-	Skips       string `json:"skips"`
+	// What the events are summarized by: certname, containing_class or resource
+	SubjectType string            `json:"subject_type"`
+	Subject     EventCountSubject `json:"subject"`
+	Failures    int               `json:"failures"`
+	Successes   int               `json:"successes"`
+	Noops       int               `json:"noops"`
+	Skips       int               `json:"skips"`
+}
+
+/*
+EventCountSubject - The node, class or resource events were counted for.
+
+Type is only set for resources. Title is the certname, the class name or the
+resource title, and empty for events outside any class.
+*/
+type EventCountSubject struct {
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title"`
 }
 
 /*
 AggregateEventCounts struct
-Response data structure for aggregate-event-counts query end-points.
+Response data structure for aggregate-event-counts query end-points, one per
+summarize_by value.
 
-More detail here: http://docs.puppetlabs.com/puppetdb/1.6/api/query/v3/aggregate-event-counts.html#get-v3aggregate-event-counts
+More detail here: https://puppet.com/docs/puppetdb/latest/api/query/v4/aggregate-event-counts.html#response-format
 */
 type AggregateEventCounts struct {
-	Failures  string `json:"failures"`
-	Successes string `json:"successes"`
-	Noops     string `json:"noops"`
-	Skips     string `json:"skips"`
-	Total     string `json:"total"`
+	SummarizeBy string `json:"summarize_by"`
+	Failures    int    `json:"failures"`
+	Successes   int    `json:"successes"`
+	Noops       int    `json:"noops"`
+	Skips       int    `json:"skips"`
+	Total       int    `json:"total"`
 }
@@ -0,0 +1,120 @@
+package puppetdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReportDecode(t *testing.T) {
+	var report Report
+	err := json.Unmarshal([]byte(`{
+		"certname": "foo.example.com",
+		"hash": "32c821673e647b0650717db467abc51d9949fd9a",
+		"status": "changed",
+		"noop": false,
+		"corrective_change": true,
+		"start_time": "2020-09-13T12:26:00.000Z",
+		"end_time": "2020-09-13T12:26:40.000Z",
+		"cached_catalog_status": "not_used",
+		"resource_events": {"href": "/pdb/query/v4/reports/32c8/events", "data": [
+			{"resource_type": "File", "resource_title": "/etc/hosts", "property": "mode", "status": "success",
+			 "old_value": "0600", "new_value": "0644", "containment_path": ["Stage[main]", "Main"]}
+		]},
+		"metrics": {"href": "/pdb/query/v4/reports/32c8/metrics", "data": [
+			{"category": "time", "name": "total", "value": 2.3}
+		]},
+		"logs": {"href": "/pdb/query/v4/reports/32c8/logs", "data": [
+			{"level": "notice", "message": "mode changed '0600' to '0644'", "source": "/Stage[main]/Main/File[/etc/hosts]/mode",
+			 "tags": ["notice"], "time": "2020-09-13T12:26:39.000Z", "file": null, "line": null}
+		]}
+	}`), &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.CorrectiveChange || report.EndTime.Sub(report.StartTime).Seconds() != 40 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.ResourceEvents.Data) != 1 || report.ResourceEvents.Data[0].NewValue != "0644" {
+		t.Errorf("unexpected events %+v", report.ResourceEvents)
+	}
+	if len(report.Metrics.Data) != 1 || report.Metrics.Data[0].Value != 2.3 {
+		t.Errorf("unexpected metrics %+v", report.Metrics)
+	}
+	if len(report.Logs.Data) != 1 || report.Logs.Data[0].Level != "notice" {
+		t.Errorf("unexpected logs %+v", report.Logs)
+	}
+}
+
+func TestStoreReport(t *testing.T) {
+	var payload map[string]json.RawMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("version") != "8" {
+			t.Errorf("unexpected command version %s", r.URL.Query().Get("version"))
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.Write([]byte(`{"uuid": "abc"}`))
+	}))
+	defer ts.Close()
+	server := NewServer(ts.URL + "/")
+
+	if _, err := server.StoreReport(ReportWireFormat{Certname: "foo.example.com", Status: "unchanged"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"resources", "metrics", "logs"} {
+		if string(payload[key]) != "[]" {
+			t.Errorf("%s = %s, want []", key, payload[key])
+		}
+	}
+	for _, key := range []string{"producer_timestamp", "cached_catalog_status", "noop_pending", "corrective_change"} {
+		if _, ok := payload[key]; !ok {
+			t.Errorf("no %s in payload", key)
+		}
+	}
+}
+
+func TestReportWireFormatNulls(t *testing.T) {
+	report := ReportWireFormat{
+		Resources: []ReportResource{{Events: []ResourceEvent{{}}}},
+		Metrics:   []Metric{},
+		Logs:      []Log{{}},
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	resource := payload["resources"].([]interface{})[0].(map[string]interface{})
+	event := resource["events"].([]interface{})[0].(map[string]interface{})
+	log := payload["logs"].([]interface{})[0].(map[string]interface{})
+
+	checks := []struct {
+		name   string
+		object map[string]interface{}
+		keys   []string
+	}{
+		{"report", payload, []string{"producer", "code_id"}},
+		{"resource", resource, []string{"file", "line"}},
+		{"event", event, []string{"name"}},
+		{"log", log, []string{"file", "line"}},
+	}
+	for _, check := range checks {
+		for _, key := range check.keys {
+			if value, ok := check.object[key]; !ok || value != nil {
+				t.Errorf("%s %s = %v (present %v), want null", check.name, key, value, ok)
+			}
+		}
+	}
+
+	var decoded ReportWireFormat
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Producer != nil || decoded.CodeID != nil || decoded.Logs[0].Line != nil {
+		t.Errorf("nulls did not round-trip: %+v", decoded)
+	}
+}
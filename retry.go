@@ -0,0 +1,273 @@
+package puppetdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+RetryPolicy - Controls how requests are retried on transient failures, such
+as PuppetDB restarting or its PostgreSQL backend failing over.
+
+Set one on a Server with SetRetryPolicy, by default requests are not retried.
+Queries are always safe to retry. Commands are only retried when they are
+idempotent, see IdempotentCommands.
+*/
+type RetryPolicy struct {
+	// Total number of attempts, including the first. 1 or less disables retries
+	MaxAttempts int
+	// Delay before the first retry, doubled on each subsequent attempt
+	MinBackoff time.Duration
+	// Upper bound on the delay between attempts
+	MaxBackoff time.Duration
+	// Fraction of each delay, between 0 and 1, that is randomized to avoid
+	// many clients retrying in lock step
+	Jitter float64
+	// HTTP status codes that are worth retrying
+	RetryableStatus []int
+	// Decides whether a transport error, such as a refused connection, is
+	// worth retrying. When nil every transport error is retried
+	RetryableError func(error) bool
+}
+
+/*
+IdempotentCommands - Commands which may be safely submitted more than once.
+
+Replacing facts or a catalog, or deactivating a node, leaves PuppetDB in the
+same state however often it is repeated, while a stored report would be
+duplicated.
+*/
+var IdempotentCommands = map[string]bool{
+	"replace facts":        true,
+	"replace catalog":      true,
+	"deactivate node":      true,
+	"configure expiration": true,
+}
+
+/*
+DefaultRetryPolicy - A policy of four attempts over roughly seven seconds,
+retrying connection failures and 502, 503 and 504 responses.
+*/
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:     4,
+		MinBackoff:      time.Second,
+		MaxBackoff:      30 * time.Second,
+		Jitter:          0.2,
+		RetryableStatus: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// SetRetryPolicy sets the policy for retrying failed requests, nil disables retries
+func (s *Server) SetRetryPolicy(policy *RetryPolicy) {
+	s.RetryPolicy = policy
+}
+
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	for _, retryable := range p.RetryableStatus {
+		if code == retryable {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) retryableError(err error) bool {
+	if p.RetryableError == nil {
+		return true
+	}
+	return p.RetryableError(err)
+}
+
+// backoff returns the delay before the given retry, counting from 1
+func (p *RetryPolicy) backoff(retry int, resp *http.Response) time.Duration {
+	delay := time.Duration(float64(p.MinBackoff) * math.Pow(2, float64(retry-1)))
+	if p.MaxBackoff > 0 && (delay > p.MaxBackoff || delay < 0) {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	if resp != nil {
+		if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > delay {
+			delay = retryAfter
+		}
+	}
+	return delay
+}
+
+// parseRetryAfter reads a Retry-After header in either seconds or HTTP date form
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// streamingKey marks a context whose response body is read incrementally
+type streamingKey struct{}
+
+func withStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+func streaming(ctx context.Context) bool {
+	return ctx.Value(streamingKey{}) != nil
+}
+
+// extraTimeoutKey carries time to add to the server's HTTPTimeout, for
+// requests that PuppetDB is asked to hold open
+type extraTimeoutKey struct{}
+
+func withExtraTimeout(ctx context.Context, d time.Duration) context.Context {
+	if d <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, extraTimeoutKey{}, d)
+}
+
+func extraTimeout(ctx context.Context) time.Duration {
+	d, _ := ctx.Value(extraTimeoutKey{}).(time.Duration)
+	return d
+}
+
+/*
+sendStreaming - Send a request whose body is read incrementally, with the
+server's HTTPTimeout bounding only the wait for the response to begin. The
+body may then be read for as long as the request's context allows.
+*/
+func (s *Server) sendStreaming(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	var timer *time.Timer
+	if s.HTTPTimeout > 0 {
+		timer = time.AfterFunc(s.HTTPTimeout, cancel)
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if timer != nil && !timer.Stop() && req.Context().Err() == nil {
+		if err == nil {
+			// The timeout fired just as the response arrived
+			resp.Body.Close()
+			err = context.DeadlineExceeded
+		}
+		err = fmt.Errorf("puppetdb: no response from %s within %s: %w", req.URL.Path, s.HTTPTimeout, err)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelBody{resp.Body, cancel}
+	return resp, nil
+}
+
+// cancelBody releases a streaming request's context once its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+/*
+do - Send a request to PuppetDB, retrying according to the server's policy.
+
+The body is buffered so it can be replayed on each attempt. A 401 response is
+replayed once, without counting as an attempt, if the server's Authenticator
+can refresh its credentials. Non-2xx responses are returned as an *APIError
+once retries are exhausted.
+*/
+func (s *Server) do(ctx context.Context, method string, fullURL string, body []byte, contentType string, retryable bool) (*http.Response, error) {
+	attempts := 1
+	if retryable {
+		attempts = s.RetryPolicy.attempts()
+	}
+
+	client := &http.Client{Transport: s.HTTPTransport, Timeout: s.HTTPTimeout}
+	if client.Timeout > 0 {
+		client.Timeout += extraTimeout(ctx)
+	}
+	if streaming(ctx) {
+		// The timeout would also cut off reading the body
+		client.Timeout = 0
+	}
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if body == nil {
+			req.Body = http.NoBody
+		}
+		// Set any additional headers such as authentication, proxy, etc
+		req.Header = s.header()
+		req.Header.Set("Content-Type", contentType)
+		if s.Authenticator != nil {
+			if err := s.Authenticator.Authenticate(ctx, req); err != nil {
+				return nil, err
+			}
+		}
+
+		var resp *http.Response
+		if streaming(ctx) {
+			resp, err = s.sendStreaming(client, req)
+		} else {
+			resp, err = client.Do(req)
+		}
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return resp, nil
+		}
+
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && s.Authenticator != nil && !refreshed {
+			refreshed = true
+			if s.Authenticator.Refresh(ctx) == nil {
+				resp.Body.Close()
+				attempt--
+				continue
+			}
+		}
+
+		lastAttempt := attempt >= attempts || ctx.Err() != nil
+		if err != nil {
+			if lastAttempt || !s.RetryPolicy.retryableError(err) {
+				return nil, err
+			}
+		} else {
+			if lastAttempt || !s.RetryPolicy.retryableStatus(resp.StatusCode) {
+				defer resp.Body.Close()
+				return nil, newAPIError(resp)
+			}
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(s.RetryPolicy.backoff(attempt, resp))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package puppetdb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var requests int
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if requests%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"uuid": "abc"}`))
+	}))
+	defer ts.Close()
+
+	server := NewServer(ts.URL + "/")
+	server.SetRetryPolicy(&RetryPolicy{
+		MaxAttempts:     3,
+		MinBackoff:      time.Millisecond,
+		RetryableStatus: []int{http.StatusServiceUnavailable},
+	})
+
+	if _, err := server.DeactivateNode("foo", nil); err != nil {
+		t.Fatalf("expected the command to succeed after retries, got %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("made %d requests, want 3", requests)
+	}
+	for _, body := range bodies {
+		if body == "" || body != bodies[0] {
+			t.Errorf("request body was not replayed: %q", bodies)
+		}
+	}
+
+	requests = 0
+	if _, err := server.StoreReport(ReportWireFormat{}, nil); err == nil {
+		t.Error("expected store report to fail without retrying")
+	}
+	if requests != 1 {
+		t.Errorf("store report made %d requests, want 1", requests)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	policy := &RetryPolicy{MinBackoff: time.Millisecond}
+	resp := &http.Response{Header: http.Header{"Retry-After": {"2"}}}
+	if delay := policy.backoff(1, resp); delay != 2*time.Second {
+		t.Errorf("backoff() = %v, want 2s", delay)
+	}
+	if delay := policy.backoff(3, nil); delay != 4*time.Millisecond {
+		t.Errorf("backoff() = %v, want 4ms", delay)
+	}
+}
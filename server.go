@@ -1,27 +1,38 @@
 package puppetdb
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
-	"os/exec"
-	"os/user"
-	"strings"
+	"net/url"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-type body io.Reader
+/*
+DefaultQueryPOSTThreshold - Length of the encoded query string, in bytes,
+above which queries are sent by POST rather than in the URL, comfortably
+below the 8KB request line limit of common proxies and of PuppetDB's Jetty.
+*/
+const DefaultQueryPOSTThreshold = 4096
+
+// defaultHeadersLock guards the Headers of Servers not built by a constructor
+var defaultHeadersLock sync.RWMutex
 
 /*
 Server Representation of a PuppetDB server instance.
 
 Use NewServer to create a new instance.
+
+A Server may be shared by many goroutines once configured. Request state is
+kept per call, and headers may be changed at any time with SetHeader or
+SetToken, but the remaining fields and setters should only be used before
+the Server is shared.
 */
 type Server struct {
 	BaseURL           string
@@ -29,69 +40,102 @@ type Server struct {
 	HTTPTransport     http.RoundTripper
 	HTTPTimeout       time.Duration
 	Headers           map[string]string
-	Body              body
+	RetryPolicy       *RetryPolicy
+	RBAC              *RBACClient
+	Authenticator     Authenticator
+	// Length of the encoded query string above which queries are sent by
+	// POST, 0 for DefaultQueryPOSTThreshold and negative to use GET unless forced
+	QueryPOSTThreshold int
+
+	// Guards Headers, shared by copies of the Server since it is passed by value
+	headersLock *sync.RWMutex
 }
 
-// SetHTTPTimeout to set custom Timeout of http.Client
+// SetHTTPTimeout to set custom Timeout of http.Client. Iterators only apply it
+// until the response begins, leaving the stream to the context
 func (s *Server) SetHTTPTimeout(t time.Duration) {
 	s.HTTPTimeout = t
 }
 
-// SetHeader the header
+func (s *Server) headerLock() *sync.RWMutex {
+	if s.headersLock == nil {
+		return &defaultHeadersLock
+	}
+	return s.headersLock
+}
+
+// SetHeader the header, safe to use while requests are in flight
 func (s *Server) SetHeader(key string, value string) {
+	lock := s.headerLock()
+	lock.Lock()
+	defer lock.Unlock()
+	if s.Headers == nil {
+		s.Headers = make(map[string]string)
+	}
 	s.Headers[key] = value
 }
 
+// header returns a snapshot of the headers to send with a request
+func (s *Server) header() http.Header {
+	lock := s.headerLock()
+	lock.RLock()
+	defer lock.RUnlock()
+	header := make(http.Header, len(s.Headers))
+	for key, value := range s.Headers {
+		header.Set(key, value)
+	}
+	return header
+}
+
 // SetToken - Sets up Puppet Enterprise RBAC Token Header
 func (s *Server) SetToken(token string) {
 	s.SetHeader("X-Authentication", token)
 }
 
-// Authenticate - get Puppet Token from RBAC (using puppet-access) for Puppet Server
-func (s *Server) Authenticate() {
-	// See if the user supplied a token from the ENV or cli if not, try to fetch an existing one from disk or attempt to create it
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatal(err)
-	}
-	tokenDir := homeDir + "/.puppetlabs/token"
+// SetRBACClient sets the client Authenticate uses to acquire Puppet Enterprise RBAC tokens
+func (s *Server) SetRBACClient(client *RBACClient) {
+	s.RBAC = client
+}
 
-	// If the token already exists in the user's home dir read it, otherwise create it
-	if _, err := os.Stat(tokenDir); os.IsNotExist(err) {
-		user, err := user.Current()
-		if err != nil {
-			log.Fatal("Unable to determine user")
-		}
-		fmt.Printf("Authenticate using puppet-access...\n")
-		cmd := exec.Command("/opt/puppetlabs/bin/puppet-access", "login",
-			"--username", user.Username,
-			"--lifetime", "4h",
-			"--service-url", fmt.Sprintf("https://%s:4433/rbac-api", s.puppetServer()))
-		cmd.Stdout = os.Stdout
-		cmd.Stdin = os.Stdin
-		cmd.Stderr = os.Stdout
-		err = cmd.Run()
+/*
+Authenticate - Get a Puppet Enterprise RBAC token and use it for subsequent
+requests.
+
+An existing token in ~/.puppetlabs/token is reused while it is still valid,
+otherwise a new one is requested from the RBAC API using the credentials of
+the server's RBACClient. When no RBACClient has been set, one for the RBAC
+service on port 4433 of the PuppetDB host is used, which can only use a
+cached token.
+
+Unless an Authenticator has already been set, this installs an
+RBACAuthenticator, so an expired token is replaced transparently on later
+requests.
+*/
+func (s *Server) Authenticate() error {
+	return s.AuthenticateContext(context.Background())
+}
+
+// AuthenticateContext - Authenticate with a context for cancellation and deadlines
+func (s *Server) AuthenticateContext(ctx context.Context) error {
+	if s.RBAC == nil {
+		host, err := s.puppetServer()
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
+		s.RBAC = NewRBACClient(fmt.Sprintf("https://%s:4433/rbac-api", host), nil)
+		s.RBAC.HTTPTransport = s.HTTPTransport
 	}
-	token, err := ioutil.ReadFile(tokenDir)
-	if err != nil {
-		log.Fatalf("Unable to read %s and no token was specified or abled to be created", tokenDir)
+	if s.Authenticator == nil {
+		s.SetAuthenticator(RBACAuthenticator{Client: s.RBAC})
 	}
-	s.SetToken(string(token))
+
 	// Validate by checking PuppetDB version
-	ver, _ := s.QueryVersion()
-	log.Debug("PuppetDB Version: " + ver.Version)
-	if len(ver.Version) < 1 {
-		log.Info("Token expired or invalid, removing token, re-authenticating...")
-		cmd2 := exec.Command("/opt/puppetlabs/bin/puppet-access", "delete-token-file")
-		cmd2.Stdout = os.Stdout
-		cmd2.Stdin = os.Stdin
-		cmd2.Stderr = os.Stdout
-		err = cmd2.Run()
-		s.Authenticate()
+	ver, err := s.QueryVersionContext(ctx)
+	if err != nil {
+		return err
 	}
+	log.Debug("PuppetDB Version: " + ver.Version)
+	return nil
 }
 
 // SetCACertificate sets CA Cert
@@ -158,13 +202,15 @@ func (s *Server) setTransport() {
 }
 
 // puppetServer - Parse PuppetServer from URL for ulterior motives
-func (s *Server) puppetServer() string {
-	// req, err := http.NewRequest("GET", s.BaseURL, nil)
-	// if err != nil {
-	// 	log.Panic("PuppetServer hostname could not be determined")
-	// }
-	// return req.URL.Host
-	return strings.Split(strings.Split(s.BaseURL, "/")[2], ":")[0] // janky, but it will work for now
+func (s *Server) puppetServer() (string, error) {
+	baseURL, err := url.Parse(s.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	if baseURL.Hostname() == "" {
+		return "", fmt.Errorf("unable to determine the Puppet server from %q", s.BaseURL)
+	}
+	return baseURL.Hostname(), nil
 }
 
 func newServer(baseURL string, httpTransport http.RoundTripper) Server {
@@ -173,5 +219,6 @@ func newServer(baseURL string, httpTransport http.RoundTripper) Server {
 		HTTPTransport: httpTransport,
 		HTTPTimeout:   time.Second * 30,
 		Headers:       make(map[string]string),
+		headersLock:   &sync.RWMutex{},
 	}
 }
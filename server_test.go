@@ -1,10 +1,17 @@
 package puppetdb
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/ChrisHirsch/puppetdb-client-go/ast"
 )
 
 func TestSetHTTPTimeout(t *testing.T) {
@@ -51,3 +58,46 @@ func TestServer_SetHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestServerConcurrentUse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.ContentLength > 0 {
+			t.Errorf("GET %s sent a body", r.URL.Path)
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/facts"):
+			w.Write([]byte(`[{"certname": "foo", "name": "kernel", "value": "Linux"}]`))
+		case strings.HasSuffix(r.URL.Path, "/nodes"):
+			w.Write([]byte(`[{"certname": "foo"}]`))
+		case strings.HasSuffix(r.URL.Path, "/cmd/v1"):
+			w.Write([]byte(`{"uuid": "abc"}`))
+		default:
+			w.Write([]byte(`{"version": "6.0.0"}`))
+		}
+	}))
+	defer ts.Close()
+
+	server := NewServer(ts.URL + "/")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			server.SetToken(fmt.Sprintf("token-%d", i))
+			opts := &QueryOptions{Limit: i + 1}
+			if facts, err := server.QueryFacts(ast.Equals{Field: "name", Value: "kernel"}, opts); err != nil || len(*facts) != 1 {
+				t.Errorf("QueryFacts: %v", err)
+			}
+			if _, err := server.QueryNodesContext(context.Background(), nil, opts); err != nil {
+				t.Errorf("QueryNodes: %v", err)
+			}
+			if _, err := server.DeactivateNode("foo", nil); err != nil {
+				t.Errorf("DeactivateNode: %v", err)
+			}
+			if _, err := server.QueryVersion(); err != nil {
+				t.Errorf("QueryVersion: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,179 @@
+package puppetdb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultSSLDir is where Puppet keeps its certificates on an agent or server
+const DefaultSSLDir = "/etc/puppetlabs/puppet/ssl"
+
+/*
+TLSOptions - Certificates used to talk to PuppetDB over TLS with a client
+certificate, as required by open source PuppetDB on port 8081.
+*/
+type TLSOptions struct {
+	// PEM file of the CA to trust, normally the Puppet CA
+	CACertificateFile string
+	// PEM file of the client certificate, signed by the Puppet CA
+	ClientCertificateFile string
+	// PEM file of the client certificate's private key
+	ClientKeyFile string
+	// Optional PEM file of certificate revocation lists to check the
+	// PuppetDB certificate against
+	CRLFile string
+	// Accept a revocation list that is past its next update, rather than
+	// refusing to connect until it is refreshed
+	AllowExpiredCRL bool
+	// Optional name to verify the PuppetDB certificate against, when it
+	// differs from the host in the base URL
+	ServerName string
+}
+
+/*
+SSLDirTLSOptions - TLSOptions for certname using the standard Puppet ssldir
+layout:
+
+	<ssldir>/certs/ca.pem
+	<ssldir>/certs/<certname>.pem
+	<ssldir>/private_keys/<certname>.pem
+	<ssldir>/crl.pem (used when present)
+*/
+func SSLDirTLSOptions(ssldir string, certname string) TLSOptions {
+	opts := TLSOptions{
+		CACertificateFile:     filepath.Join(ssldir, "certs", "ca.pem"),
+		ClientCertificateFile: filepath.Join(ssldir, "certs", certname+".pem"),
+		ClientKeyFile:         filepath.Join(ssldir, "private_keys", certname+".pem"),
+	}
+	crl := filepath.Join(ssldir, "crl.pem")
+	if _, err := os.Stat(crl); err == nil {
+		opts.CRLFile = crl
+	}
+	return opts
+}
+
+/*
+NewMutualTLSServer - Create new instance of a server authenticating with a
+client certificate.
+
+When opts has a CRLFile, connections are refused once a revocation list is
+past its next update, unless opts.AllowExpiredCRL is set.
+*/
+func NewMutualTLSServer(baseURL string, opts TLSOptions) (Server, error) {
+	config, err := newTLSConfig(opts)
+	if err != nil {
+		return Server{}, err
+	}
+	server := newServer(baseURL, &http.Transport{TLSClientConfig: config})
+	server.CACertificateFile = opts.CACertificateFile
+	return server, nil
+}
+
+/*
+NewPuppetSSLServer - Create new instance of a server authenticating with the
+Puppet certificate of certname, read from ssldir (usually DefaultSSLDir).
+
+Connections are refused while the ssldir's crl.pem is past its next update.
+To tolerate a stale CRL, pass SSLDirTLSOptions with AllowExpiredCRL set to
+NewMutualTLSServer instead.
+*/
+func NewPuppetSSLServer(baseURL string, ssldir string, certname string) (Server, error) {
+	return NewMutualTLSServer(baseURL, SSLDirTLSOptions(ssldir, certname))
+}
+
+func newTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{ServerName: opts.ServerName}
+
+	if opts.CACertificateFile != "" {
+		certs, err := ioutil.ReadFile(opts.CACertificateFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(certs) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CACertificateFile)
+		}
+	}
+
+	if opts.ClientCertificateFile != "" || opts.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertificateFile, opts.ClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if opts.CRLFile != "" {
+		crls, err := loadCRLs(opts.CRLFile)
+		if err != nil {
+			return nil, err
+		}
+		config.VerifyPeerCertificate = func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+			return checkRevocation(crls, verifiedChains, opts.AllowExpiredCRL)
+		}
+	}
+
+	return config, nil
+}
+
+func loadCRLs(file string) ([]*x509.RevocationList, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var crls []*x509.RevocationList
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "X509 CRL" {
+			continue
+		}
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", file, err)
+		}
+		crls = append(crls, crl)
+	}
+	if len(crls) == 0 {
+		return nil, fmt.Errorf("no certificate revocation lists found in %s", file)
+	}
+	return crls, nil
+}
+
+// checkRevocation fails if any certificate in the verified chains has been
+// revoked by a CRL signed by its issuer, or unless allowExpired, if that CRL
+// is past its next update
+func checkRevocation(crls []*x509.RevocationList, verifiedChains [][]*x509.Certificate, allowExpired bool) error {
+	now := time.Now()
+	for _, chain := range verifiedChains {
+		for i := 0; i+1 < len(chain); i++ {
+			cert, issuer := chain[i], chain[i+1]
+			for _, crl := range crls {
+				if crl.CheckSignatureFrom(issuer) != nil {
+					continue
+				}
+				if !allowExpired && !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate) {
+					return fmt.Errorf("certificate revocation list of %s expired at %s", issuer.Subject.CommonName, crl.NextUpdate)
+				}
+				for _, revoked := range crl.RevokedCertificates {
+					if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+						return errors.New("certificate for " + cert.Subject.CommonName + " has been revoked")
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
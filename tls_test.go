@@ -0,0 +1,153 @@
+package puppetdb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, serial int64, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert, key, der}
+}
+
+func writePEM(t *testing.T, file string, blockType string, der []byte) {
+	os.MkdirAll(filepath.Dir(file), 0700)
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewPuppetSSLServer(t *testing.T) {
+	ca := newTestCert(t, 1, "Puppet CA", nil)
+	puppetdb := newTestCert(t, 2, "puppetdb.example.com", ca)
+	agent := newTestCert(t, 3, "agent.example.com", ca)
+
+	ssldir, err := ioutil.TempDir("", "ssldir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(ssldir)
+	writePEM(t, filepath.Join(ssldir, "certs", "ca.pem"), "CERTIFICATE", ca.der)
+	writePEM(t, filepath.Join(ssldir, "certs", "agent.example.com.pem"), "CERTIFICATE", agent.der)
+	keyDER, _ := x509.MarshalECPrivateKey(agent.key)
+	writePEM(t, filepath.Join(ssldir, "private_keys", "agent.example.com.pem"), "EC PRIVATE KEY", keyDER)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "agent.example.com" {
+			t.Error("expected the agent client certificate")
+		}
+		w.Write([]byte(`{"version": "7.0.0"}`))
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{puppetdb.der}, PrivateKey: puppetdb.key}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	server, err := NewPuppetSSLServer(ts.URL+"/", ssldir, "agent.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := server.QueryVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Version != "7.0.0" {
+		t.Errorf("unexpected version %v", version.Version)
+	}
+
+	// An expired CRL is refused, unless explicitly allowed
+	writeCRL(t, filepath.Join(ssldir, "crl.pem"), ca, 1, time.Now().Add(-time.Minute))
+	server, err = NewPuppetSSLServer(ts.URL+"/", ssldir, "agent.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.QueryVersion(); err == nil {
+		t.Error("expected an expired CRL to be rejected")
+	}
+	opts := SSLDirTLSOptions(ssldir, "agent.example.com")
+	opts.AllowExpiredCRL = true
+	server, err = NewMutualTLSServer(ts.URL+"/", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.QueryVersion(); err != nil {
+		t.Errorf("unexpected error with an allowed expired CRL: %v", err)
+	}
+
+	// Revoke the PuppetDB certificate and expect the connection to be refused
+	writeCRL(t, filepath.Join(ssldir, "crl.pem"), ca, 2, time.Now().Add(time.Hour), puppetdb.cert.SerialNumber)
+	server, err = NewPuppetSSLServer(ts.URL+"/", ssldir, "agent.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.QueryVersion(); err == nil {
+		t.Error("expected a revoked server certificate to be rejected")
+	}
+}
+
+func writeCRL(t *testing.T, file string, ca *testCert, number int64, nextUpdate time.Time, revoked ...*big.Int) {
+	var entries []pkix.RevokedCertificate
+	for _, serial := range revoked {
+		entries = append(entries, pkix.RevokedCertificate{SerialNumber: serial, RevocationTime: time.Now()})
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(number),
+		ThisUpdate:          time.Now().Add(-time.Hour),
+		NextUpdate:          nextUpdate,
+		RevokedCertificates: entries,
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, file, "X509 CRL", crl)
+}